@@ -0,0 +1,106 @@
+package scanner
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Target struct {
+	Host string
+	Port int
+}
+
+func (t Target) String() string {
+	return net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+}
+
+type Result struct {
+	Target
+	Open bool
+	// Banner is the first line the service sent on its own after the connection was opened, if any.
+	Banner string
+	Err    error
+}
+
+// Scanner probes TCP targets with a fixed pool of workers, just like the multichannel scanner in tcp-scanner-final.
+type Scanner struct {
+	Workers     int
+	Timeout     time.Duration
+	BannerWait  time.Duration
+	GrabBanners bool
+}
+
+func New(workers int, timeout time.Duration) *Scanner {
+	return &Scanner{
+		Workers:    workers,
+		Timeout:    timeout,
+		BannerWait: 2 * time.Second,
+	}
+}
+
+type job struct {
+	index  int
+	target Target
+}
+
+type result struct {
+	index  int
+	result Result
+}
+
+func (s *Scanner) worker(jobs chan job, results chan result) {
+	for j := range jobs {
+		results <- result{j.index, s.probe(j.target)}
+	}
+}
+
+func (s *Scanner) probe(t Target) Result {
+	res := Result{Target: t}
+	conn, err := net.DialTimeout("tcp", t.String(), s.Timeout)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	defer conn.Close()
+	res.Open = true
+
+	if s.GrabBanners {
+		conn.SetReadDeadline(time.Now().Add(s.BannerWait))
+		if line, err := bufio.NewReader(conn).ReadString('\n'); err == nil || line != "" {
+			res.Banner = strings.TrimSpace(line)
+		}
+	}
+	return res
+}
+
+// Scan probes every target and returns the results in the same order as targets.
+func (s *Scanner) Scan(targets []Target) []Result {
+	workers := s.Workers
+	if workers <= 0 {
+		workers = 100
+	}
+	jobs := make(chan job, workers)
+	results := make(chan result)
+
+	for i := 0; i < workers; i++ {
+		go s.worker(jobs, results)
+	}
+
+	go func() {
+		for i, t := range targets {
+			jobs <- job{i, t}
+		}
+		close(jobs)
+	}()
+
+	ret := make([]Result, len(targets))
+	for range targets {
+		r := <-results
+		ret[r.index] = r.result
+	}
+	close(results)
+	return ret
+}
@@ -1,79 +1,204 @@
 package main
 
 import (
-	"archive/zip"
-	"bytes"
+	"flag"
 	"fmt"
-	"github.com/PuerkitoBio/goquery"
-	"github.com/bilalcaliskan/blackhat-go/ch3/bing-metadata/metadata"
-	"io/ioutil"
+	"github.com/bilalcaliskan/blackhat-go/ch3/bing-metadata/download"
+	"github.com/bilalcaliskan/blackhat-go/ch3/bing-metadata/report"
+	"github.com/bilalcaliskan/blackhat-go/ch3/bing-metadata/search"
+	"github.com/bilalcaliskan/blackhat-go/internal/httpclient"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
+	"time"
 )
 
-func handler(i int, s *goquery.Selection) {
-	url, ok := s.Find("a").Attr("href")
-	if !ok {
-		return
+func handler(i int, e report.Entry) {
+	name := e.URL
+	if name == "" {
+		name = e.Path
 	}
-
-	fmt.Printf("%d: %s\n", i, url)
-	res, err := http.Get(url)
-	if err != nil {
+	fmt.Printf("%d: %s\n", i, name)
+	if e.Err != "" {
+		log.Printf("%21s %s\n", "error", e.Err)
 		return
 	}
 
-	buf, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return
+	m := e.Metadata
+	log.Printf(
+		"%21s %s - %s %s\n",
+		m.Creator,
+		m.LastModifiedBy,
+		m.Application,
+		m.GetMajorVersion())
+	if m.Revision != "" || m.TotalTime > 0 {
+		log.Printf("%21s %s, edited for %d minutes\n", "revision", m.Revision, m.TotalTime)
+	}
+	if m.EXIF != nil && m.EXIF.GPS != nil {
+		log.Printf("%21s %s\n", "gps", m.EXIF.GPS)
 	}
-	defer res.Body.Close()
+	for _, a := range m.Artifacts {
+		log.Printf("%21s %s (%s)\n", a.Kind, a.Value, a.Source)
+	}
+}
 
-	r, err := zip.NewReader(bytes.NewReader(buf), int64(len(buf)))
+// writeReport writes the full report to path in format, json or csv.
+func writeReport(r *report.Report, path, format string) error {
+	f, err := os.Create(path)
 	if err != nil {
-		return
+		return err
+	}
+	switch format {
+	case "json":
+		err = r.WriteJSON(f)
+	case "csv":
+		err = r.WriteCSV(f)
+	default:
+		err = fmt.Errorf("unknown report format %q", format)
 	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
 
-	cp, ap, err := metadata.NewProperties(r)
+func writeUsers(r *report.Report, path string) error {
+	f, err := os.Create(path)
 	if err != nil {
-		return
+		return err
+	}
+	err = r.WriteUsers(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
 	}
+	return err
+}
 
-	log.Printf(
-		"%21s %s - %s %s\n",
-		cp.Creator,
-		cp.LastModifiedBy,
-		ap.Application,
-		ap.GetMajorVersion())
+func newProvider(name, urls string, pages int, client *http.Client) (search.SearchProvider, error) {
+	switch name {
+	case "bing":
+		b := search.NewBingHTML()
+		b.Pages = pages
+		b.Client = client
+		return b, nil
+	case "bing-api":
+		key := os.Getenv("BING_API_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("missing required environment variable BING_API_KEY")
+		}
+		b := search.NewBingAPI(key)
+		b.Client = client
+		return b, nil
+	case "file":
+		if urls == "" {
+			return nil, fmt.Errorf("the file provider needs -urls")
+		}
+		return search.NewURLList(urls), nil
+	default:
+		return nil, fmt.Errorf("unknown search provider %q", name)
+	}
 }
 
 func main() {
-	if len(os.Args) != 3 {
-		log.Fatalln("Missing required argument. Usage: main.go <domain> <ext>")
+	providerName := flag.String("provider", "bing", "search backend: bing, bing-api (needs BING_API_KEY) or file")
+	urls := flag.String("urls", "", "file with one document URL per line, for -provider file")
+	pages := flag.Int("pages", 1, "number of Bing result pages to scrape")
+	dir := flag.String("dir", "downloads", "directory the documents are stored in, named by SHA-256")
+	workers := flag.Int("workers", 10, "number of concurrent downloads")
+	perHost := flag.Int("per-host", 2, "maximum concurrent downloads per host")
+	maxSize := flag.Int64("max-size", 50<<20, "largest document to download in bytes, 0 for no limit")
+	timeout := flag.Duration("timeout", 30*time.Second, "timeout of a single download attempt")
+	retries := flag.Int("retries", 2, "retries after network errors, 429 and 5xx responses")
+	refresh := flag.Bool("refresh", false, "download documents again even if an earlier run stored them")
+	local := flag.String("local", "", "extract every supported file below this directory instead of searching")
+	reportPath := flag.String("report", "", "write the full report to this file")
+	format := flag.String("format", "json", "report format: json or csv")
+	usersPath := flag.String("users", "", "write the unique user names to this file, one per line")
+	httpFlags := (&httpclient.Config{}).RegisterFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: main.go [flags] <domain> <ext>")
+		fmt.Fprintln(os.Stderr, "       main.go [flags] -local <dir>")
+		flag.PrintDefaults()
 	}
-	domain := os.Args[1]
-	filetype := os.Args[2]
+	flag.Parse()
 
-	q := fmt.Sprintf(
-		"site:%s && filetype:%s && instreamset:(url title):%s",
-		domain,
-		filetype,
-		filetype)
+	var entries []report.Entry
+	if *local != "" {
+		if flag.NArg() != 0 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		var err error
+		if entries, err = report.Walk(*local); err != nil {
+			log.Fatalln(err)
+		}
+	} else {
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		client, err := httpFlags.Client()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		provider, err := newProvider(*providerName, *urls, *pages, client)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		d := download.New(*dir)
+		d.Client = client
+		d.Workers = *workers
+		d.PerHost = *perHost
+		d.MaxSize = *maxSize
+		d.Timeout = *timeout
+		d.Retries = *retries
+		d.Refresh = *refresh
+		entries = fetch(provider, d, flag.Arg(0), flag.Arg(1))
+		if err := httpclient.Close(client); err != nil {
+			log.Println(err)
+		}
+	}
 
-	search := fmt.Sprintf("http://www.bing.com/search?q=%s", url.QueryEscape(q))
-	res, err := http.Get(search)
-	if err != nil {
-		return
+	for i, e := range entries {
+		handler(i, e)
+	}
+	r := report.New(entries)
+	fmt.Println()
+	if err := r.WriteSummary(os.Stdout); err != nil {
+		log.Fatalln(err)
 	}
+	if *reportPath != "" {
+		if err := writeReport(r, *reportPath, *format); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if *usersPath != "" {
+		if err := writeUsers(r, *usersPath); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
 
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+// fetch searches for documents, downloads them and extracts their metadata.
+func fetch(provider search.SearchProvider, d *download.Downloader, domain, filetype string) []report.Entry {
+	results, err := provider.Search(domain, filetype)
 	if err != nil {
 		log.Panicln(err)
 	}
-	defer res.Body.Close()
-	// TODO: Fix, not working
-	s := "html body div#b_content ol#b_results li.b_algo h2"
-	doc.Find(s).Each(handler)
+
+	downloaded, err := d.Download(results)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	entries := make([]report.Entry, len(downloaded))
+	for i, r := range downloaded {
+		if r.Err != nil {
+			entries[i] = report.Entry{URL: r.URL, Err: r.Err.Error()}
+			continue
+		}
+		entries[i] = report.File(r.Path)
+		entries[i].URL = r.URL
+	}
+	return entries
 }
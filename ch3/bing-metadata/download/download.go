@@ -0,0 +1,289 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrTooLarge is returned for documents bigger than Downloader.MaxSize.
+var ErrTooLarge = errors.New("download: document exceeds the size limit")
+
+// DefaultContentTypes are the accepted Content-Type prefixes. They let documents and images through and reject the
+// HTML error and landing pages many sites serve for missing files.
+var DefaultContentTypes = []string{"application/", "image/", "binary/"}
+
+// StatusError is returned when a server answers with anything but 200 OK.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("download: server answered %s", e.Status)
+}
+
+// temporary reports whether the request may succeed when retried.
+func (e *StatusError) temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// ContentTypeError is returned for responses whose Content-Type is not in Downloader.ContentTypes.
+type ContentTypeError struct {
+	ContentType string
+}
+
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("download: unexpected content type %q", e.ContentType)
+}
+
+// Result is the outcome of downloading one URL. Path is the file the document was stored at, named after the
+// SHA-256 of its content.
+type Result struct {
+	URL         string `json:"url"`
+	Path        string `json:"path,omitempty"`
+	SHA256      string `json:"sha256,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	// Cached is set when the document was taken from an earlier run instead of being downloaded again.
+	Cached bool  `json:"-"`
+	Err    error `json:"-"`
+}
+
+// Downloader fetches documents with a fixed pool of workers and stores them in Dir. At most PerHost requests are
+// sent to the same host at a time.
+type Downloader struct {
+	Dir     string
+	Workers int
+	PerHost int
+	// MaxSize is the largest document accepted in bytes, zero means no limit.
+	MaxSize int64
+	// Timeout bounds a single attempt, including reading the body.
+	Timeout time.Duration
+	// Retries is the number of additional attempts after network errors, 429 and 5xx responses. The delay starts
+	// at Backoff and doubles after every attempt.
+	Retries int
+	Backoff time.Duration
+	// ContentTypes are the accepted Content-Type prefixes, an empty list accepts anything.
+	ContentTypes []string
+	UserAgent    string
+	// Refresh downloads URLs again even if they are in the index of an earlier run.
+	Refresh bool
+	Client  *http.Client
+
+	mu    sync.Mutex
+	hosts map[string]chan struct{}
+}
+
+func New(dir string) *Downloader {
+	return &Downloader{
+		Dir:          dir,
+		Workers:      10,
+		PerHost:      2,
+		MaxSize:      50 << 20,
+		Timeout:      30 * time.Second,
+		Retries:      2,
+		Backoff:      time.Second,
+		ContentTypes: DefaultContentTypes,
+		UserAgent:    "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+		Client:       http.DefaultClient,
+	}
+}
+
+type job struct {
+	index int
+	url   string
+}
+
+type result struct {
+	index  int
+	result Result
+}
+
+// Download fetches every URL and returns the results in the same order as urls. Successful downloads are recorded
+// in the index of Dir, so later runs reuse them unless Refresh is set.
+func (d *Downloader) Download(urls []string) ([]Result, error) {
+	store, err := openStore(d.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := d.Workers
+	if workers <= 0 {
+		workers = 10
+	}
+	jobs := make(chan job, workers)
+	results := make(chan result)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for j := range jobs {
+				results <- result{j.index, d.download(store, j.url)}
+			}
+		}()
+	}
+
+	go func() {
+		for i, u := range urls {
+			jobs <- job{i, u}
+		}
+		close(jobs)
+	}()
+
+	ret := make([]Result, len(urls))
+	for range urls {
+		r := <-results
+		ret[r.index] = r.result
+	}
+	close(results)
+	return ret, store.save()
+}
+
+func (d *Downloader) download(store *store, rawurl string) Result {
+	if !d.Refresh {
+		if r, ok := store.lookup(rawurl); ok {
+			r.Cached = true
+			return r
+		}
+	}
+
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return Result{URL: rawurl, Err: err}
+	}
+
+	delay := d.Backoff
+	for attempt := 0; ; attempt++ {
+		// The host slot is only held during the request, so backing off doesn't stall other downloads from the
+		// same host.
+		release := d.acquire(u.Host)
+		r := d.fetch(store, rawurl)
+		release()
+		if r.Err == nil {
+			store.record(r)
+			return r
+		}
+		if attempt >= d.Retries || !temporary(r.Err) {
+			return r
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (d *Downloader) fetch(store *store, rawurl string) Result {
+	r := Result{URL: rawurl}
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequest(http.MethodGet, rawurl, nil)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	if d.UserAgent != "" {
+		req.Header.Set("User-Agent", d.UserAgent)
+	}
+	if d.Timeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), d.Timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		r.Err = &StatusError{StatusCode: res.StatusCode, Status: res.Status}
+		return r
+	}
+	r.ContentType = res.Header.Get("Content-Type")
+	if !d.accept(r.ContentType) {
+		r.Err = &ContentTypeError{ContentType: r.ContentType}
+		return r
+	}
+	if d.MaxSize > 0 && res.ContentLength > d.MaxSize {
+		r.Err = ErrTooLarge
+		return r
+	}
+
+	var body io.Reader = res.Body
+	if d.MaxSize > 0 {
+		// Read one byte more than allowed to detect oversized bodies without a Content-Length.
+		body = io.LimitReader(res.Body, d.MaxSize+1)
+	}
+	r.Path, r.SHA256, r.Size, r.Err = store.write(body, extension(req.URL.Path), d.MaxSize)
+	return r
+}
+
+func (d *Downloader) accept(contentType string) bool {
+	if len(d.ContentTypes) == 0 {
+		return true
+	}
+	media, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		// Servers that don't send a type at all get the benefit of the doubt, the extractors sniff the content.
+		return contentType == ""
+	}
+	for _, prefix := range d.ContentTypes {
+		if strings.HasPrefix(media, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// acquire blocks until fewer than PerHost requests to host are in flight and returns the function that releases
+// the slot again.
+func (d *Downloader) acquire(host string) func() {
+	if d.PerHost <= 0 {
+		return func() {}
+	}
+	d.mu.Lock()
+	if d.hosts == nil {
+		d.hosts = make(map[string]chan struct{})
+	}
+	sem, ok := d.hosts[host]
+	if !ok {
+		sem = make(chan struct{}, d.PerHost)
+		d.hosts[host] = sem
+	}
+	d.mu.Unlock()
+
+	sem <- struct{}{}
+	return func() { <-sem }
+}
+
+func temporary(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.temporary()
+	}
+	var ct *ContentTypeError
+	if errors.As(err, &ct) || errors.Is(err, ErrTooLarge) {
+		return false
+	}
+	// Client errors arrive wrapped in *url.Error, so look at the cause. Only timeouts, connections reset by the peer
+	// and bodies cut short may go away on their own, DNS, refused connection, TLS and malformed URL errors won't.
+	var nerr net.Error
+	if errors.As(err, &nerr) && nerr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
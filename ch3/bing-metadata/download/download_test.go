@@ -0,0 +1,176 @@
+package download
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func urlError(err error) error {
+	return &url.Error{Op: "Get", URL: "https://example.com/a.pdf", Err: err}
+}
+
+func TestTemporary(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"timeout", urlError(&net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}), true},
+		{"connection reset", urlError(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), true},
+		{"body cut short", fmt.Errorf("reading body: %w", io.ErrUnexpectedEOF), true},
+		{"context deadline", urlError(context.DeadlineExceeded), true},
+		{"server error", &StatusError{StatusCode: 503, Status: "503 Service Unavailable"}, true},
+		{"no such host", urlError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}}), false},
+		{"connection refused", urlError(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), false},
+		{"bad certificate", urlError(x509.UnknownAuthorityError{}), false},
+		{"unsupported scheme", urlError(errors.New(`unsupported protocol scheme "ftp"`)), false},
+		{"not found", &StatusError{StatusCode: 404, Status: "404 Not Found"}, false},
+		{"too large", ErrTooLarge, false},
+	}
+	for _, tt := range tests {
+		if got := temporary(tt.err); got != tt.want {
+			t.Errorf("%s: temporary(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+// testDownloader returns a downloader storing into a temporary directory without retries.
+func testDownloader(t *testing.T) *Downloader {
+	d := New(t.TempDir())
+	d.Retries = 0
+	d.Backoff = time.Millisecond
+	return d
+}
+
+func TestDownloadMaxSize(t *testing.T) {
+	body := strings.Repeat("x", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		if r.URL.Path == "/streamed.pdf" {
+			// Flushing before writing the body makes the server send it chunked, without a Content-Length.
+			w.(http.Flusher).Flush()
+		}
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	d := testDownloader(t)
+	d.MaxSize = 10
+	results, err := d.Download([]string{srv.URL + "/sized.pdf", srv.URL + "/streamed.pdf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if !errors.Is(r.Err, ErrTooLarge) {
+			t.Errorf("%s: got %v, want ErrTooLarge", r.URL, r.Err)
+		}
+	}
+
+	d.MaxSize = 100
+	results, err = d.Download([]string{srv.URL + "/sized.pdf", srv.URL + "/streamed.pdf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Err != nil || r.Size != 100 {
+			t.Errorf("%s: got size %d, %v", r.URL, r.Size, r.Err)
+		}
+	}
+}
+
+func TestDownloadIndex(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/pdf")
+		io.WriteString(w, "%PDF-1.4")
+	}))
+	defer srv.Close()
+	urls := []string{srv.URL + "/a.pdf"}
+
+	d := testDownloader(t)
+	first, err := d.Download(urls)
+	if err != nil || first[0].Err != nil || first[0].Cached {
+		t.Fatalf("first run: %+v, %v", first, err)
+	}
+	if _, err := os.Stat(first[0].Path); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new downloader for the same directory reads the index of the first run.
+	d = &Downloader{Dir: d.Dir, Client: http.DefaultClient}
+	second, err := d.Download(urls)
+	if err != nil || !second[0].Cached || second[0].Path != first[0].Path {
+		t.Errorf("second run: %+v, %v", second, err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("cached run sent a request, %d in total", n)
+	}
+
+	d.Refresh = true
+	third, err := d.Download(urls)
+	if err != nil || third[0].Cached || third[0].SHA256 != first[0].SHA256 {
+		t.Errorf("refresh: %+v, %v", third, err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("refresh didn't download again, %d requests in total", n)
+	}
+}
+
+func TestDownloadBackoffReleasesHost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/busy.pdf" {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		io.WriteString(w, "%PDF-1.4")
+	}))
+	defer srv.Close()
+
+	d := testDownloader(t)
+	d.PerHost = 1
+	d.Retries = 1
+	d.Backoff = 500 * time.Millisecond
+	done := make(chan Result, 1)
+	go func() {
+		r, _ := d.Download([]string{srv.URL + "/busy.pdf"})
+		done <- r[0]
+	}()
+
+	// Give the first download time to fail once and start backing off.
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	r := d.download(mustStore(t, d.Dir), srv.URL+"/ok.pdf")
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	if waited := time.Since(start); waited > 250*time.Millisecond {
+		t.Errorf("download waited %s for the host slot held during backoff", waited)
+	}
+	if busy := <-done; busy.Err == nil {
+		t.Error("busy URL succeeded")
+	}
+}
+
+func mustStore(t *testing.T, dir string) *store {
+	t.Helper()
+	s, err := openStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
@@ -0,0 +1,142 @@
+package download
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// IndexFile is the name of the file in Downloader.Dir that maps URLs to the documents downloaded from them.
+const IndexFile = "index.json"
+
+// store keeps downloaded documents in a directory, each named after the SHA-256 of its content so identical files
+// served under different URLs are only stored once.
+type store struct {
+	dir   string
+	mu    sync.Mutex
+	index map[string]Result
+}
+
+func openStore(dir string) (*store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	s := &store{dir: dir, index: make(map[string]Result)}
+	data, err := ioutil.ReadFile(filepath.Join(dir, IndexFile))
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.index); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ReadIndex returns the URL to document mapping of an earlier run in dir.
+func ReadIndex(dir string) (map[string]Result, error) {
+	s, err := openStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	return s.index, nil
+}
+
+// lookup returns the result recorded for rawurl if its document is still on disk.
+func (s *store) lookup(rawurl string) (Result, bool) {
+	s.mu.Lock()
+	r, ok := s.index[rawurl]
+	s.mu.Unlock()
+	if !ok {
+		return r, false
+	}
+	if _, err := os.Stat(r.Path); err != nil {
+		return r, false
+	}
+	return r, true
+}
+
+func (s *store) record(r Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index[r.URL] = r
+}
+
+func (s *store) save() error {
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.index, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(s.dir, IndexFile), data)
+}
+
+// write stores body under its SHA-256 and returns the path, the hex encoded hash and the size. Bodies larger than
+// max are discarded with ErrTooLarge.
+func (s *store) write(body io.Reader, ext string, max int64) (string, string, int64, error) {
+	tmp, err := ioutil.TempFile(s.dir, ".download-")
+	if err != nil {
+		return "", "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", "", 0, err
+	}
+	if max > 0 && n > max {
+		return "", "", 0, ErrTooLarge
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	name := filepath.Join(s.dir, sum+ext)
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return "", "", 0, err
+	}
+	return name, sum, n, nil
+}
+
+// extension returns the lower-cased extension of a URL path, limited to short alphanumeric values.
+func extension(urlpath string) string {
+	ext := strings.ToLower(path.Ext(urlpath))
+	if len(ext) < 2 || len(ext) > 6 {
+		return ""
+	}
+	for _, c := range ext[1:] {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return ""
+		}
+	}
+	return ext
+}
+
+// writeFile replaces name atomically so an interrupted run never leaves a truncated index behind.
+func writeFile(name string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(name), ".index-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
@@ -0,0 +1,187 @@
+package metadata
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// Artifact kinds reported by NewArtifacts.
+const (
+	ArtifactTemplate       = "attached_template"
+	ArtifactExternalTarget = "external_target"
+	ArtifactCommentAuthor  = "comment_author"
+	ArtifactRevisionAuthor = "revision_author"
+	ArtifactImageEXIF      = "image_exif"
+)
+
+// Artifact is a piece of information found inside a document rather than in its properties, e.g. the path of the
+// template it was created from or the author of a tracked change. Source is the archive member it was found in and
+// Detail further qualifies the value, e.g. the relationship type or the EXIF field.
+type Artifact struct {
+	Kind   string `json:"kind"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type relationships struct {
+	Relationships []struct {
+		Type       string `xml:"Type,attr"`
+		Target     string `xml:"Target,attr"`
+		TargetMode string `xml:"TargetMode,attr"`
+	} `xml:"Relationship"`
+}
+
+// NewArtifacts walks every member of an Office Open XML archive and collects attached templates, external
+// relationship targets, comment and revision authors and the EXIF data of embedded images. Members that can't be
+// parsed are skipped.
+func NewArtifacts(r *zip.Reader) []Artifact {
+	a := &artifacts{seen: make(map[Artifact]bool)}
+	for _, f := range r.File {
+		name := strings.ToLower(f.Name)
+		switch {
+		case strings.HasSuffix(name, ".rels"):
+			a.rels(f)
+		case strings.HasSuffix(name, ".xml") && !strings.HasPrefix(name, "docprops/"):
+			a.xml(f)
+		case strings.Contains(name, "/media/") && isEXIFImage(name):
+			a.image(f)
+		}
+	}
+	return a.list
+}
+
+type artifacts struct {
+	list []Artifact
+	seen map[Artifact]bool
+}
+
+func (a *artifacts) add(kind, value, source, detail string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	art := Artifact{Kind: kind, Value: value, Source: source, Detail: detail}
+	if a.seen[art] {
+		return
+	}
+	a.seen[art] = true
+	a.list = append(a.list, art)
+}
+
+func (a *artifacts) rels(f *zip.File) {
+	var rels relationships
+	if err := process(f, &rels); err != nil {
+		return
+	}
+	for _, rel := range rels.Relationships {
+		typ := path.Base(rel.Type)
+		switch {
+		case typ == "attachedTemplate":
+			a.add(ArtifactTemplate, rel.Target, f.Name, typ)
+		case rel.TargetMode == "External":
+			a.add(ArtifactExternalTarget, rel.Target, f.Name, typ)
+		}
+	}
+}
+
+// xml scans a document part for the authors of comments and revisions:
+//   - w:comment/@w:author in word/comments.xml
+//   - author elements in the legacy xl/comments*.xml
+//   - person/@displayName in xl/persons and p:cmAuthor/@name in ppt/commentAuthors.xml
+//   - userInfo/@name in xl/revisions/userNames.xml
+//   - the author attribute of tracked changes: w:ins, w:del and the *Change elements such as w:rPrChange
+func (a *artifacts) xml(f *zip.File) {
+	rc, err := f.Open()
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+
+	d := xml.NewDecoder(rc)
+	var inAuthor bool
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "comment":
+				a.add(ArtifactCommentAuthor, attr(t, "author"), f.Name, t.Name.Local)
+			case "cmAuthor":
+				a.add(ArtifactCommentAuthor, attr(t, "name"), f.Name, t.Name.Local)
+			case "person":
+				a.add(ArtifactCommentAuthor, attr(t, "displayName"), f.Name, t.Name.Local)
+			case "userInfo":
+				a.add(ArtifactRevisionAuthor, attr(t, "name"), f.Name, t.Name.Local)
+			case "author":
+				inAuthor = true
+				text.Reset()
+			case "ins", "del":
+				a.add(ArtifactRevisionAuthor, attr(t, "author"), f.Name, t.Name.Local)
+			default:
+				if strings.HasSuffix(t.Name.Local, "Change") {
+					a.add(ArtifactRevisionAuthor, attr(t, "author"), f.Name, t.Name.Local)
+				}
+			}
+		case xml.CharData:
+			if inAuthor {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if inAuthor && t.Name.Local == "author" {
+				a.add(ArtifactCommentAuthor, text.String(), f.Name, t.Name.Local)
+				inAuthor = false
+			}
+		}
+	}
+}
+
+func (a *artifacts) image(f *zip.File) {
+	// Embedded images are usually small, but don't trust the archive.
+	const maxImage = 32 << 20
+	if f.UncompressedSize64 > maxImage {
+		return
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(rc, maxImage))
+	if err != nil {
+		return
+	}
+
+	e := parseImage(data).exif
+	if e == nil {
+		return
+	}
+	for _, field := range e.Fields() {
+		a.add(ArtifactImageEXIF, field[1], f.Name, field[0])
+	}
+}
+
+func isEXIFImage(name string) bool {
+	switch path.Ext(name) {
+	case ".jpg", ".jpeg", ".jpe", ".tif", ".tiff", ".png":
+		return true
+	}
+	return false
+}
+
+func attr(e xml.StartElement, local string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
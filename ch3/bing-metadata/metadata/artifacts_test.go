@@ -0,0 +1,50 @@
+package metadata
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestNewArtifacts(t *testing.T) {
+	data := docx(t, map[string]string{
+		"word/_rels/settings.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/attachedTemplate" Target="file:///\\fs01\templates\Corp.dotm" TargetMode="External"/>
+</Relationships>`,
+		"word/comments.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:comments xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:comment w:id="0" w:author="Reviewer One" w:initials="RO"><w:p><w:r><w:t>Check this</w:t></w:r></w:p></w:comment>
+</w:comments>`,
+		"word/document.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:body>
+    <w:p>
+      <w:ins w:id="1" w:author="Editor A"><w:r><w:t>new</w:t></w:r></w:ins>
+      <w:del w:id="2" w:author="Editor B"><w:r><w:delText>old</w:delText></w:r></w:del>
+      <w:r><w:rPr><w:b/><w:rPrChange w:id="3" w:author="Editor C"><w:rPr/></w:rPrChange></w:rPr></w:r>
+      <w:customXml w:author="Not A Reviser"/>
+    </w:p>
+  </w:body>
+</w:document>`,
+	})
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[Artifact]bool)
+	for _, a := range NewArtifacts(zr) {
+		got[a] = true
+	}
+	want := map[Artifact]bool{
+		{ArtifactTemplate, `file:///\\fs01\templates\Corp.dotm`, "word/_rels/settings.xml.rels", "attachedTemplate"}: true,
+		{ArtifactCommentAuthor, "Reviewer One", "word/comments.xml", "comment"}:                                      true,
+		{ArtifactRevisionAuthor, "Editor A", "word/document.xml", "ins"}:                                             true,
+		{ArtifactRevisionAuthor, "Editor B", "word/document.xml", "del"}:                                             true,
+		{ArtifactRevisionAuthor, "Editor C", "word/document.xml", "rPrChange"}:                                       true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewArtifacts() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,229 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// cfbSignature starts every Compound File Binary, the OLE2 container of legacy Office documents.
+var cfbSignature = []byte{0xd0, 0xcf, 0x11, 0xe0, 0xa1, 0xb1, 0x1a, 0xe1}
+
+const (
+	cfbEndOfChain = 0xfffffffe
+	cfbFreeSect   = 0xffffffff
+	cfbDirEntry   = 128
+	cfbStream     = 2
+	cfbRoot       = 5
+)
+
+var errCFBCorrupt = errors.New("metadata: corrupt compound file")
+
+// CompoundFile reads the streams of an OLE2 Compound File Binary (.doc, .xls, .ppt, ...).
+type CompoundFile struct {
+	r          io.ReaderAt
+	size       int64
+	sectorSize int64
+	miniSize   int64
+	miniCutoff uint32
+	fat        []uint32
+	miniFAT    []uint32
+	miniStream []byte
+	entries    []cfbEntry
+}
+
+type cfbEntry struct {
+	name  string
+	kind  byte
+	start uint32
+	size  uint64
+}
+
+func NewCompoundFile(r io.ReaderAt, size int64) (*CompoundFile, error) {
+	header := make([]byte, 512)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:8], cfbSignature) {
+		return nil, errors.New("metadata: not a compound file")
+	}
+
+	le := binary.LittleEndian
+	sectorShift := le.Uint16(header[0x1e:])
+	miniShift := le.Uint16(header[0x20:])
+	if sectorShift != 9 && sectorShift != 12 || miniShift != 6 {
+		return nil, errCFBCorrupt
+	}
+	cf := &CompoundFile{
+		r:          r,
+		size:       size,
+		sectorSize: 1 << sectorShift,
+		miniSize:   1 << miniShift,
+		miniCutoff: le.Uint32(header[0x38:]),
+	}
+
+	// The DIFAT lists the sectors holding the FAT, the first 109 entries are stored in the header.
+	numFAT := le.Uint32(header[0x2c:])
+	if int64(numFAT) > size/cf.sectorSize {
+		return nil, errCFBCorrupt
+	}
+	var difat []uint32
+	for i := 0; i < 109 && uint32(len(difat)) < numFAT; i++ {
+		difat = append(difat, le.Uint32(header[0x4c+4*i:]))
+	}
+	// Further DIFAT sectors form a chain of their own, stop as soon as every FAT sector is known
+	// and refuse loops or sector numbers past the end of the file.
+	visited := make(map[uint32]bool)
+	for next := le.Uint32(header[0x44:]); uint32(len(difat)) < numFAT; {
+		if next >= cfbEndOfChain || visited[next] {
+			return nil, errCFBCorrupt
+		}
+		visited[next] = true
+		sector, err := cf.sector(next)
+		if err != nil {
+			return nil, err
+		}
+		for i := int64(0); i < cf.sectorSize/4-1 && uint32(len(difat)) < numFAT; i++ {
+			difat = append(difat, le.Uint32(sector[4*i:]))
+		}
+		next = le.Uint32(sector[cf.sectorSize-4:])
+	}
+	for _, s := range difat[:numFAT] {
+		sector, err := cf.sector(s)
+		if err != nil {
+			return nil, err
+		}
+		for i := int64(0); i < cf.sectorSize/4; i++ {
+			cf.fat = append(cf.fat, le.Uint32(sector[4*i:]))
+		}
+	}
+
+	dir, err := cf.chain(le.Uint32(header[0x30:]))
+	if err != nil {
+		return nil, err
+	}
+	for off := 0; off+cfbDirEntry <= len(dir); off += cfbDirEntry {
+		e := dir[off : off+cfbDirEntry]
+		nameLen := int(le.Uint16(e[64:]))
+		if nameLen > 64 {
+			nameLen = 64
+		}
+		cf.entries = append(cf.entries, cfbEntry{
+			name:  utf16LE(e[:nameLen]),
+			kind:  e[66],
+			start: le.Uint32(e[116:]),
+			size:  le.Uint64(e[120:]) & 0xffffffff,
+		})
+	}
+	if len(cf.entries) == 0 || cf.entries[0].kind != cfbRoot {
+		return nil, errCFBCorrupt
+	}
+
+	// Streams below the cutoff live in the mini stream, owned by the root entry.
+	miniFAT, err := cf.chain(le.Uint32(header[0x3c:]))
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i+4 <= len(miniFAT); i += 4 {
+		cf.miniFAT = append(cf.miniFAT, le.Uint32(miniFAT[i:]))
+	}
+	if cf.miniStream, err = cf.chain(cf.entries[0].start); err != nil {
+		return nil, err
+	}
+	return cf, nil
+}
+
+func (cf *CompoundFile) sector(n uint32) ([]byte, error) {
+	off := (int64(n) + 1) * cf.sectorSize
+	if off+cf.sectorSize > cf.size {
+		return nil, errCFBCorrupt
+	}
+	buf := make([]byte, cf.sectorSize)
+	if _, err := cf.r.ReadAt(buf, off); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// chain concatenates the sectors of a FAT chain.
+func (cf *CompoundFile) chain(start uint32) ([]byte, error) {
+	var buf []byte
+	for n, steps := start, 0; n != cfbEndOfChain && n != cfbFreeSect; steps++ {
+		if int(n) >= len(cf.fat) || steps > len(cf.fat) {
+			return nil, errCFBCorrupt
+		}
+		sector, err := cf.sector(n)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, sector...)
+		n = cf.fat[n]
+	}
+	return buf, nil
+}
+
+// miniChain concatenates the mini sectors of a mini FAT chain.
+func (cf *CompoundFile) miniChain(start uint32) ([]byte, error) {
+	var buf []byte
+	for n, steps := start, 0; n != cfbEndOfChain && n != cfbFreeSect; steps++ {
+		off := int64(n) * cf.miniSize
+		if int(n) >= len(cf.miniFAT) || steps > len(cf.miniFAT) || off+cf.miniSize > int64(len(cf.miniStream)) {
+			return nil, errCFBCorrupt
+		}
+		buf = append(buf, cf.miniStream[off:off+cf.miniSize]...)
+		n = cf.miniFAT[n]
+	}
+	return buf, nil
+}
+
+// Streams returns the names of all streams in the file.
+func (cf *CompoundFile) Streams() []string {
+	var names []string
+	for _, e := range cf.entries {
+		if e.kind == cfbStream {
+			names = append(names, e.name)
+		}
+	}
+	return names
+}
+
+// Stream returns the content of the named stream. Names are matched case-insensitively, as in the
+// specification, regardless of the storage they are in.
+func (cf *CompoundFile) Stream(name string) ([]byte, error) {
+	for _, e := range cf.entries {
+		if e.kind != cfbStream || !strings.EqualFold(e.name, name) {
+			continue
+		}
+		var data []byte
+		var err error
+		if e.size < uint64(cf.miniCutoff) {
+			data, err = cf.miniChain(e.start)
+		} else {
+			data, err = cf.chain(e.start)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(data)) < e.size {
+			return nil, errCFBCorrupt
+		}
+		return data[:e.size], nil
+	}
+	return nil, fmt.Errorf("metadata: stream %q not found", name)
+}
+
+func utf16LE(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		c := uint16(b[i]) | uint16(b[i+1])<<8
+		if c == 0 {
+			break
+		}
+		u = append(u, c)
+	}
+	return string(utf16.Decode(u))
+}
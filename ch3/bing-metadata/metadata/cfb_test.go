@@ -0,0 +1,90 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompoundFile(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "summary.doc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cf, err := NewCompoundFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cf.Stream("\x05SummaryInformation"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOLEExtract(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "summary.doc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := OLEExtractor{}.Extract(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Metadata{
+		Format:         "ole2",
+		Title:          "Budget 2004",
+		Creator:        "Jörg Author",
+		LastModifiedBy: "lastsaver",
+		Company:        "ACME Corp",
+		Manager:        "Big Boss",
+		Application:    "Microsoft Word 9.0",
+		AppVersion:     "11.0000",
+		Template:       "Normal.dot",
+		Created:        "2004-05-06T07:08:09Z",
+		Custom:         map[string]string{"Classification": "Internal"},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("Extract() =\n%+v\nwant\n%+v", m, want)
+	}
+}
+
+// cfbHeader builds a compound file of the given number of 512 byte sectors whose header declares
+// numFAT FAT sectors and a single DIFAT sector at difatStart.
+func cfbHeader(sectors int, numFAT, difatStart uint32) []byte {
+	data := make([]byte, (sectors+1)*512)
+	le := binary.LittleEndian
+	copy(data, cfbSignature)
+	le.PutUint16(data[0x1e:], 9)
+	le.PutUint16(data[0x20:], 6)
+	le.PutUint32(data[0x2c:], numFAT)
+	le.PutUint32(data[0x44:], difatStart)
+	le.PutUint32(data[0x48:], 1)
+	return data
+}
+
+func TestCompoundFileDIFAT(t *testing.T) {
+	le := binary.LittleEndian
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"too many FAT sectors", cfbHeader(10, 11, cfbEndOfChain)},
+		{"chain ends early", cfbHeader(300, 200, cfbEndOfChain)},
+		{"out of range sector", cfbHeader(300, 200, 5000)},
+		{"loop", func() []byte {
+			// Sector 0 holds 127 DIFAT entries and points back to itself, 109+127 < 240.
+			data := cfbHeader(300, 240, 0)
+			le.PutUint32(data[512+508:], 0)
+			return data
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewCompoundFile(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
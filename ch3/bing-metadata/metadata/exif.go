@@ -0,0 +1,376 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// EXIF holds the identifying fields of an image's EXIF data. Timestamps are RFC 3339 strings; EXIF stores the
+// camera's local time, which is reported as UTC unless the image carries an offset tag.
+type EXIF struct {
+	Make              string `json:"make,omitempty"`
+	Model             string `json:"model,omitempty"`
+	Software          string `json:"software,omitempty"`
+	Artist            string `json:"artist,omitempty"`
+	Copyright         string `json:"copyright,omitempty"`
+	DateTime          string `json:"date_time,omitempty"`
+	DateTimeOriginal  string `json:"date_time_original,omitempty"`
+	DateTimeDigitized string `json:"date_time_digitized,omitempty"`
+	SerialNumber      string `json:"serial_number,omitempty"`
+	GPS               *GPS   `json:"gps,omitempty"`
+}
+
+// GPS is the location an image was taken at in decimal degrees. Altitude is in meters above sea level.
+type GPS struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude,omitempty"`
+	Time      string  `json:"time,omitempty"`
+}
+
+func (g *GPS) String() string {
+	return fmt.Sprintf("%.6f,%.6f", g.Latitude, g.Longitude)
+}
+
+// Fields returns the non-empty fields as name/value pairs in a stable order.
+func (e *EXIF) Fields() [][2]string {
+	all := [][2]string{
+		{"Make", e.Make},
+		{"Model", e.Model},
+		{"Software", e.Software},
+		{"Artist", e.Artist},
+		{"Copyright", e.Copyright},
+		{"DateTime", e.DateTime},
+		{"DateTimeOriginal", e.DateTimeOriginal},
+		{"DateTimeDigitized", e.DateTimeDigitized},
+		{"SerialNumber", e.SerialNumber},
+	}
+	if e.GPS != nil {
+		all = append(all, [2]string{"GPS", e.GPS.String()})
+	}
+	var fields [][2]string
+	for _, f := range all {
+		if f[1] != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// TIFF tags of IFD0.
+const (
+	tagMake      = 0x010f
+	tagModel     = 0x0110
+	tagSoftware  = 0x0131
+	tagDateTime  = 0x0132
+	tagArtist    = 0x013b
+	tagCopyright = 0x8298
+	tagExifIFD   = 0x8769
+	tagGPSIFD    = 0x8825
+	tagXPAuthor  = 0x9c9d
+)
+
+// Tags of the Exif sub-IFD.
+const (
+	tagDateTimeOriginal    = 0x9003
+	tagDateTimeDigitized   = 0x9004
+	tagOffsetTime          = 0x9010
+	tagOffsetTimeOriginal  = 0x9011
+	tagOffsetTimeDigitized = 0x9012
+	tagBodySerialNumber    = 0xa431
+)
+
+// Tags of the GPS sub-IFD.
+const (
+	tagGPSLatitudeRef  = 0x01
+	tagGPSLatitude     = 0x02
+	tagGPSLongitudeRef = 0x03
+	tagGPSLongitude    = 0x04
+	tagGPSAltitudeRef  = 0x05
+	tagGPSAltitude     = 0x06
+	tagGPSTimeStamp    = 0x07
+	tagGPSDateStamp    = 0x1d
+)
+
+var errNoEXIF = errors.New("metadata: no exif data")
+
+// jpegEXIF returns the TIFF structure stored in the APP1 Exif segment of a JPEG.
+func jpegEXIF(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil
+	}
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xff {
+		marker := data[pos+1]
+		// Start of scan, the image data follows and no more metadata segments.
+		if marker == 0xda {
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		pos += 2 + length
+	}
+	return nil
+}
+
+// ParseEXIF decodes IFD0 and the Exif and GPS sub-IFDs of a TIFF structure, as embedded in JPEG APP1 segments and
+// PNG eXIf chunks or as a whole TIFF file.
+func ParseEXIF(tiff []byte) (*EXIF, error) {
+	t, err := newTIFFReader(tiff)
+	if err != nil {
+		return nil, err
+	}
+	e := &EXIF{}
+	var exifIFD, gpsIFD uint32
+	for _, entry := range t.ifd(t.order.Uint32(tiff[4:])) {
+		switch entry.tag {
+		case tagMake:
+			e.Make = t.ascii(entry)
+		case tagModel:
+			e.Model = t.ascii(entry)
+		case tagSoftware:
+			e.Software = t.ascii(entry)
+		case tagDateTime:
+			e.DateTime = t.ascii(entry)
+		case tagArtist:
+			e.Artist = t.ascii(entry)
+		case tagCopyright:
+			e.Copyright = t.ascii(entry)
+		case tagExifIFD:
+			exifIFD = t.uint(entry)
+		case tagGPSIFD:
+			gpsIFD = t.uint(entry)
+		case tagXPAuthor:
+			if e.Artist == "" {
+				e.Artist = t.utf16(entry)
+			}
+		}
+	}
+
+	var offset, offsetOriginal, offsetDigitized string
+	if exifIFD != 0 {
+		for _, entry := range t.ifd(exifIFD) {
+			switch entry.tag {
+			case tagDateTimeOriginal:
+				e.DateTimeOriginal = t.ascii(entry)
+			case tagDateTimeDigitized:
+				e.DateTimeDigitized = t.ascii(entry)
+			case tagOffsetTime:
+				offset = t.ascii(entry)
+			case tagOffsetTimeOriginal:
+				offsetOriginal = t.ascii(entry)
+			case tagOffsetTimeDigitized:
+				offsetDigitized = t.ascii(entry)
+			case tagBodySerialNumber:
+				e.SerialNumber = t.ascii(entry)
+			}
+		}
+	}
+	e.DateTime = exifDate(e.DateTime, offset)
+	e.DateTimeOriginal = exifDate(e.DateTimeOriginal, offsetOriginal)
+	e.DateTimeDigitized = exifDate(e.DateTimeDigitized, offsetDigitized)
+
+	if gpsIFD != 0 {
+		e.GPS = t.gps(gpsIFD)
+	}
+	return e, nil
+}
+
+func (t *tiffReader) gps(off uint32) *GPS {
+	var lat, lon, alt []float64
+	var latRef, lonRef, date string
+	var clock []float64
+	var below bool
+	for _, entry := range t.ifd(off) {
+		switch entry.tag {
+		case tagGPSLatitudeRef:
+			latRef = t.ascii(entry)
+		case tagGPSLatitude:
+			lat = t.rationals(entry)
+		case tagGPSLongitudeRef:
+			lonRef = t.ascii(entry)
+		case tagGPSLongitude:
+			lon = t.rationals(entry)
+		case tagGPSAltitudeRef:
+			b := t.bytes(entry)
+			below = len(b) > 0 && b[0] == 1
+		case tagGPSAltitude:
+			alt = t.rationals(entry)
+		case tagGPSTimeStamp:
+			clock = t.rationals(entry)
+		case tagGPSDateStamp:
+			date = t.ascii(entry)
+		}
+	}
+	if len(lat) != 3 || len(lon) != 3 {
+		return nil
+	}
+
+	g := &GPS{
+		Latitude:  lat[0] + lat[1]/60 + lat[2]/3600,
+		Longitude: lon[0] + lon[1]/60 + lon[2]/3600,
+	}
+	if latRef == "S" {
+		g.Latitude = -g.Latitude
+	}
+	if lonRef == "W" {
+		g.Longitude = -g.Longitude
+	}
+	if len(alt) == 1 {
+		g.Altitude = alt[0]
+		if below {
+			g.Altitude = -g.Altitude
+		}
+	}
+	// GPS time is always UTC.
+	if date != "" && len(clock) == 3 {
+		stamp := fmt.Sprintf("%s %02d:%02d:%02d", date, int(clock[0]), int(clock[1]), int(clock[2]))
+		g.Time = exifDate(stamp, "+00:00")
+	}
+	return g
+}
+
+// exifDate converts an EXIF "2006:01:02 15:04:05" timestamp to RFC 3339, applying an OffsetTime tag if present.
+func exifDate(s, offset string) string {
+	if s == "" {
+		return s
+	}
+	if offset != "" {
+		if t, err := time.Parse("2006:01:02 15:04:05-07:00", s+offset); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	if t, err := time.Parse("2006:01:02 15:04:05", s); err == nil {
+		return t.Format(time.RFC3339)
+	}
+	return s
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	// value holds the raw 4 byte value field, which is an offset for values that don't fit.
+	value []byte
+}
+
+func newTIFFReader(data []byte) (*tiffReader, error) {
+	if len(data) < 8 {
+		return nil, errNoEXIF
+	}
+	t := &tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, errNoEXIF
+	}
+	if t.order.Uint16(data[2:]) != 42 {
+		return nil, errNoEXIF
+	}
+	return t, nil
+}
+
+func (t *tiffReader) ifd(off uint32) []ifdEntry {
+	if int(off)+2 > len(t.data) {
+		return nil
+	}
+	n := int(t.order.Uint16(t.data[off:]))
+	var entries []ifdEntry
+	for i := 0; i < n; i++ {
+		pos := int(off) + 2 + 12*i
+		if pos+12 > len(t.data) {
+			break
+		}
+		entries = append(entries, ifdEntry{
+			tag:   t.order.Uint16(t.data[pos:]),
+			typ:   t.order.Uint16(t.data[pos+2:]),
+			count: t.order.Uint32(t.data[pos+4:]),
+			value: t.data[pos+8 : pos+12],
+		})
+	}
+	return entries
+}
+
+var tiffTypeSize = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8, 13: 4}
+
+// bytes returns the raw value of an entry, following the offset if it doesn't fit in 4 bytes.
+func (t *tiffReader) bytes(e ifdEntry) []byte {
+	size := tiffTypeSize[e.typ] * int(e.count)
+	if size <= 4 {
+		return e.value[:size]
+	}
+	off := int(t.order.Uint32(e.value))
+	if off < 0 || off+size > len(t.data) || size < 0 {
+		return nil
+	}
+	return t.data[off : off+size]
+}
+
+func (t *tiffReader) ascii(e ifdEntry) string {
+	b := t.bytes(e)
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// uint returns the first value of a SHORT, LONG or IFD entry.
+func (t *tiffReader) uint(e ifdEntry) uint32 {
+	switch e.typ {
+	case 3:
+		return uint32(t.order.Uint16(e.value))
+	case 4, 13:
+		return t.order.Uint32(e.value)
+	}
+	return 0
+}
+
+func (t *tiffReader) rationals(e ifdEntry) []float64 {
+	if e.typ != 5 {
+		return nil
+	}
+	b := t.bytes(e)
+	var v []float64
+	for i := 0; i+8 <= len(b); i += 8 {
+		num, den := t.order.Uint32(b[i:]), t.order.Uint32(b[i+4:])
+		if den == 0 {
+			v = append(v, 0)
+			continue
+		}
+		v = append(v, float64(num)/float64(den))
+	}
+	return v
+}
+
+// utf16 decodes the Windows XP* tags, which hold UTF-16LE text in a BYTE array.
+func (t *tiffReader) utf16(e ifdEntry) string {
+	b := t.bytes(e)
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		c := binary.LittleEndian.Uint16(b[i:])
+		if c == 0 {
+			break
+		}
+		u = append(u, c)
+	}
+	return strings.TrimSpace(string(utf16.Decode(u)))
+}
@@ -0,0 +1,189 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// MaxImageSize is the largest image ImageExtractor reads into memory.
+const MaxImageSize = 64 << 20
+
+// MaxTextChunkSize is the largest compressed PNG text or XMP chunk ImageExtractor inflates, larger ones are skipped.
+const MaxTextChunkSize = 1 << 20
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// ImageExtractor reads the EXIF and XMP metadata of JPEG, TIFF and PNG images.
+type ImageExtractor struct{}
+
+func (ImageExtractor) Match(head []byte) bool {
+	return imageFormat(head) != ""
+}
+
+func (ImageExtractor) Extract(r io.ReaderAt, size int64) (*Metadata, error) {
+	if size > MaxImageSize {
+		size = MaxImageSize
+	}
+	data, err := ioutil.ReadAll(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, err
+	}
+	format := imageFormat(data)
+	if format == "" {
+		return nil, ErrUnsupported
+	}
+
+	m := &Metadata{Format: format}
+	img := parseImage(data)
+	if e := img.exif; e != nil {
+		m.EXIF = e
+		m.Creator = e.Artist
+		m.Application = e.Software
+		m.Created = e.DateTimeOriginal
+		m.Modified = e.DateTime
+	}
+	for k, v := range img.text {
+		if m.Custom == nil {
+			m.Custom = make(map[string]string)
+		}
+		m.Custom[k] = v
+	}
+	fill(&m.Title, img.text["Title"])
+	fill(&m.Creator, img.text["Author"])
+	fill(&m.Application, img.text["Software"])
+	fill(&m.Created, img.text["Creation Time"])
+
+	if img.xmp != nil {
+		if x, err := ParseXMP(img.xmp); err == nil {
+			fill(&m.Title, x.Get("dc:title"))
+			fill(&m.Subject, x.Get("dc:description"))
+			fill(&m.Keywords, x.Join("dc:subject"))
+			fill(&m.Creator, x.Join("dc:creator"))
+			fill(&m.Application, x.Get("xmp:CreatorTool"))
+			fill(&m.Created, x.Get("xmp:CreateDate"))
+			fill(&m.Modified, x.Get("xmp:ModifyDate"))
+		}
+	}
+	return m, nil
+}
+
+func imageFormat(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xff, 0xd8, 0xff}):
+		return "jpeg"
+	case bytes.HasPrefix(head, []byte("II*\x00")), bytes.HasPrefix(head, []byte("MM\x00*")):
+		return "tiff"
+	case bytes.HasPrefix(head, pngSignature):
+		return "png"
+	}
+	return ""
+}
+
+type image struct {
+	exif *EXIF
+	xmp  []byte
+	// text holds the tEXt, zTXt and iTXt chunks of PNG images.
+	text map[string]string
+}
+
+// parseImage collects the metadata blocks of an image. Missing or broken blocks are left empty.
+func parseImage(data []byte) *image {
+	img := &image{}
+	var tiff []byte
+	switch imageFormat(data) {
+	case "jpeg":
+		tiff = jpegEXIF(data)
+		img.xmp = FindXMP(data)
+	case "tiff":
+		tiff = data
+		img.xmp = FindXMP(data)
+	case "png":
+		tiff = img.png(data)
+	}
+	if tiff != nil {
+		if e, err := ParseEXIF(tiff); err == nil {
+			img.exif = e
+		}
+	}
+	return img
+}
+
+// png walks the chunks of a PNG image, storing text and XMP chunks and returning the content of the eXIf chunk.
+func (img *image) png(data []byte) []byte {
+	var tiff []byte
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		typ := string(data[pos+4 : pos+8])
+		if length < 0 || pos+12+length > len(data) {
+			break
+		}
+		chunk := data[pos+8 : pos+8+length]
+		pos += 12 + length
+
+		switch typ {
+		case "eXIf":
+			tiff = chunk
+		case "tEXt":
+			if k, v, ok := cut(chunk); ok {
+				img.addText(k, decodeCodepage(v, 28591))
+			}
+		case "zTXt":
+			// Keyword, compression method, compressed text.
+			if k, v, ok := cut(chunk); ok && len(v) > 0 {
+				if text, err := inflate(v[1:], MaxTextChunkSize); err == nil {
+					img.addText(k, decodeCodepage(text, 28591))
+				}
+			}
+		case "iTXt":
+			k, v, ok := cut(chunk)
+			if !ok || len(v) < 2 {
+				continue
+			}
+			compressed := v[0] == 1
+			// Skip the language tag and translated keyword.
+			_, v, _ = cut(v[2:])
+			_, v, ok = cut(v)
+			if !ok {
+				continue
+			}
+			if compressed {
+				var err error
+				if v, err = inflate(v, MaxTextChunkSize); err != nil {
+					continue
+				}
+			}
+			if k == "XML:com.adobe.xmp" {
+				img.xmp = v
+				continue
+			}
+			img.addText(k, string(v))
+		case "IEND":
+			return tiff
+		}
+	}
+	return tiff
+}
+
+func (img *image) addText(k string, v string) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return
+	}
+	if img.text == nil {
+		img.text = make(map[string]string)
+	}
+	img.text[k] = v
+}
+
+// cut splits b around the first NUL byte.
+func cut(b []byte) (string, []byte, bool) {
+	i := bytes.IndexByte(b, 0)
+	if i < 0 {
+		return "", nil, false
+	}
+	return string(b[:i]), b[i+1:], true
+}
@@ -0,0 +1,95 @@
+package metadata
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestImageExtract(t *testing.T) {
+	tests := []struct {
+		file   string
+		format string
+		title  string
+		custom map[string]string
+	}{
+		{"photo.jpg", "jpeg", "Office party", nil},
+		{"photo.tif", "tiff", "", nil},
+		{"photo.png", "png", "Office party", map[string]string{"Author": "Müller", "Comment": "internal build"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			m, err := extractFile(t, tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Format != tt.format || m.Title != tt.title {
+				t.Errorf("format %q, title %q, want %q, %q", m.Format, m.Title, tt.format, tt.title)
+			}
+			if m.Creator != "Jane Roe" || m.Application != "GIMP 2.10" {
+				t.Errorf("creator %q, application %q", m.Creator, m.Application)
+			}
+			if m.Created != "2021-05-06T07:00:00+02:00" || m.Modified != "2021-05-06T07:08:09Z" {
+				t.Errorf("created %q, modified %q", m.Created, m.Modified)
+			}
+			for k, v := range tt.custom {
+				if m.Custom[k] != v {
+					t.Errorf("custom %s = %q, want %q", k, m.Custom[k], v)
+				}
+			}
+
+			e := m.EXIF
+			if e == nil {
+				t.Fatal("no EXIF")
+			}
+			if e.Make != "Canon" || e.Model != "EOS 5D" || e.SerialNumber != "SN12345" {
+				t.Errorf("EXIF %+v", e)
+			}
+			g := e.GPS
+			if g == nil || math.Abs(g.Latitude-52.370139) > 1e-5 || math.Abs(g.Longitude+4.891667) > 1e-5 || g.Altitude != 12.5 {
+				t.Errorf("GPS %+v", g)
+			}
+		})
+	}
+}
+
+// pngChunk encodes a PNG chunk, the CRC is not checked by the extractor and left zero.
+func pngChunk(typ string, data []byte) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.BigEndian, uint32(len(data)))
+	b.WriteString(typ)
+	b.Write(data)
+	b.Write(make([]byte, 4))
+	return b.Bytes()
+}
+
+func TestImageTextChunkLimit(t *testing.T) {
+	var bomb bytes.Buffer
+	zw := zlib.NewWriter(&bomb)
+	zw.Write(make([]byte, MaxTextChunkSize+1))
+	zw.Close()
+
+	var small bytes.Buffer
+	zw = zlib.NewWriter(&small)
+	zw.Write([]byte("kept"))
+	zw.Close()
+
+	png := append([]byte{}, pngSignature...)
+	png = append(png, pngChunk("zTXt", append([]byte("Bomb\x00\x00"), bomb.Bytes()...))...)
+	png = append(png, pngChunk("iTXt", append([]byte("XML:com.adobe.xmp\x00\x01\x00\x00\x00"), bomb.Bytes()...))...)
+	png = append(png, pngChunk("zTXt", append([]byte("Comment\x00\x00"), small.Bytes()...))...)
+	png = append(png, pngChunk("IEND", nil)...)
+
+	m, err := ImageExtractor{}.Extract(bytes.NewReader(png), int64(len(png)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Custom["Bomb"]; ok {
+		t.Error("oversized zTXt chunk was inflated")
+	}
+	if m.Custom["Comment"] != "kept" {
+		t.Errorf("chunks after the oversized ones lost: %v", m.Custom)
+	}
+}
@@ -0,0 +1,95 @@
+package metadata
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+)
+
+// ErrUnsupported is returned by Extract when no extractor recognizes the content.
+var ErrUnsupported = errors.New("metadata: unsupported file format")
+
+// Metadata is the format independent result of an Extractor. Dates are RFC 3339 strings when they could be
+// parsed and the raw value otherwise. TotalTime is the editing time in minutes.
+type Metadata struct {
+	Format         string            `json:"format"`
+	Title          string            `json:"title,omitempty"`
+	Subject        string            `json:"subject,omitempty"`
+	Keywords       string            `json:"keywords,omitempty"`
+	Creator        string            `json:"creator,omitempty"`
+	LastModifiedBy string            `json:"last_modified_by,omitempty"`
+	Company        string            `json:"company,omitempty"`
+	Manager        string            `json:"manager,omitempty"`
+	Application    string            `json:"application,omitempty"`
+	AppVersion     string            `json:"app_version,omitempty"`
+	Producer       string            `json:"producer,omitempty"`
+	Template       string            `json:"template,omitempty"`
+	HyperlinkBase  string            `json:"hyperlink_base,omitempty"`
+	Created        string            `json:"created,omitempty"`
+	Modified       string            `json:"modified,omitempty"`
+	Revision       string            `json:"revision,omitempty"`
+	TotalTime      int               `json:"total_time,omitempty"`
+	Custom         map[string]string `json:"custom,omitempty"`
+	EXIF           *EXIF             `json:"exif,omitempty"`
+	Artifacts      []Artifact        `json:"artifacts,omitempty"`
+}
+
+// GetMajorVersion returns the Office release that wrote the document, or Unknown for other applications.
+func (m *Metadata) GetMajorVersion() string {
+	return majorVersion(m.AppVersion)
+}
+
+// Extractor reads the metadata of one file format. Match is called with the first SniffLen bytes of the file.
+type Extractor interface {
+	Match(head []byte) bool
+	Extract(r io.ReaderAt, size int64) (*Metadata, error)
+}
+
+// SniffLen is the number of leading bytes passed to Extractor.Match.
+const SniffLen = 1024
+
+// Extractors are tried in order by Extract.
+var Extractors = []Extractor{
+	OpenXMLExtractor{},
+	PDFExtractor{},
+	OLEExtractor{},
+	ImageExtractor{},
+}
+
+// Extract sniffs the content of r and hands it to the first extractor that recognizes it.
+func Extract(r io.ReaderAt, size int64) (*Metadata, error) {
+	head := make([]byte, SniffLen)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	for _, e := range Extractors {
+		if e.Match(head) {
+			return e.Extract(r, size)
+		}
+	}
+	return nil, ErrUnsupported
+}
+
+// OpenXMLExtractor reads the docProps of Office Open XML documents (docx, xlsx, pptx, ...).
+type OpenXMLExtractor struct{}
+
+func (OpenXMLExtractor) Match(head []byte) bool {
+	return len(head) >= 4 && string(head[:4]) == "PK\x03\x04"
+}
+
+func (OpenXMLExtractor) Extract(r io.ReaderAt, size int64) (*Metadata, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	props, err := NewOfficeProperties(zr)
+	if err != nil {
+		return nil, err
+	}
+	m := props.Metadata()
+	m.Artifacts = NewArtifacts(zr)
+	return m, nil
+}
@@ -0,0 +1,313 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Property IDs of the SummaryInformation stream.
+const (
+	pidTitle      = 2
+	pidSubject    = 3
+	pidAuthor     = 4
+	pidKeywords   = 5
+	pidTemplate   = 7
+	pidLastAuthor = 8
+	pidCreated    = 12
+	pidLastSaved  = 13
+	pidAppName    = 18
+	pidCodepage   = 1
+	pidDictionary = 0
+)
+
+// Property IDs of the DocumentSummaryInformation stream.
+const (
+	pidManager = 14
+	pidCompany = 15
+	pidVersion = 23
+)
+
+// Variant types used by property sets.
+const (
+	vtI2       = 0x02
+	vtI4       = 0x03
+	vtBool     = 0x0b
+	vtUI4      = 0x13
+	vtLPSTR    = 0x1e
+	vtLPWSTR   = 0x1f
+	vtFiletime = 0x40
+)
+
+const (
+	summaryInformation         = "\x05SummaryInformation"
+	documentSummaryInformation = "\x05DocumentSummaryInformation"
+)
+
+// OLEExtractor reads the SummaryInformation and DocumentSummaryInformation property sets of legacy Office
+// documents (.doc, .xls, .ppt) stored as Compound File Binary.
+type OLEExtractor struct{}
+
+func (OLEExtractor) Match(head []byte) bool {
+	return bytes.HasPrefix(head, cfbSignature)
+}
+
+func (OLEExtractor) Extract(r io.ReaderAt, size int64) (*Metadata, error) {
+	cf, err := NewCompoundFile(r, size)
+	if err != nil {
+		return nil, err
+	}
+	m := &Metadata{Format: "ole2"}
+
+	if data, err := cf.Stream(summaryInformation); err == nil {
+		sets, err := parsePropertySets(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(sets) > 0 {
+			si := sets[0]
+			m.Title = si.string(pidTitle)
+			m.Subject = si.string(pidSubject)
+			m.Creator = si.string(pidAuthor)
+			m.Keywords = si.string(pidKeywords)
+			m.Template = si.string(pidTemplate)
+			m.LastModifiedBy = si.string(pidLastAuthor)
+			m.Application = si.string(pidAppName)
+			m.Created = si.time(pidCreated)
+			m.Modified = si.time(pidLastSaved)
+		}
+	}
+
+	if data, err := cf.Stream(documentSummaryInformation); err == nil {
+		sets, err := parsePropertySets(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(sets) > 0 {
+			dsi := sets[0]
+			m.Company = dsi.string(pidCompany)
+			m.Manager = dsi.string(pidManager)
+			// The version holds the major version in the high word, e.g. 0x000b0000 for Office 2003.
+			if v, ok := dsi[pidVersion].(uint32); ok {
+				m.AppVersion = fmt.Sprintf("%d.%04d", v>>16, v&0xffff)
+			}
+		}
+		// The optional second set holds the user defined properties, named through its dictionary.
+		if len(sets) > 1 {
+			m.Custom = sets[1].custom()
+		}
+	}
+	return m, nil
+}
+
+// propertySet maps property IDs to string, uint32, bool or time.Time values. The dictionary, property 0, is
+// stored as map[uint32]string.
+type propertySet map[uint32]interface{}
+
+func (p propertySet) string(id uint32) string {
+	s, _ := p[id].(string)
+	return s
+}
+
+func (p propertySet) time(id uint32) string {
+	t, ok := p[id].(time.Time)
+	if !ok || t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (p propertySet) custom() map[string]string {
+	names, _ := p[pidDictionary].(map[uint32]string)
+	if len(names) == 0 {
+		return nil
+	}
+	custom := make(map[string]string)
+	for id, name := range names {
+		switch v := p[id].(type) {
+		case string:
+			custom[name] = v
+		case time.Time:
+			custom[name] = v.Format(time.RFC3339)
+		case nil:
+		default:
+			custom[name] = fmt.Sprint(v)
+		}
+	}
+	return custom
+}
+
+var errPropertySet = errors.New("metadata: corrupt property set")
+
+// parsePropertySets decodes the sections of a property set stream, see [MS-OLEPS].
+func parsePropertySets(data []byte) ([]propertySet, error) {
+	le := binary.LittleEndian
+	if len(data) < 28 || le.Uint16(data) != 0xfffe {
+		return nil, errPropertySet
+	}
+	n := int(le.Uint32(data[24:]))
+	var sets []propertySet
+	for i := 0; i < n; i++ {
+		hdr := 28 + 20*i
+		if hdr+20 > len(data) {
+			return nil, errPropertySet
+		}
+		off := int(le.Uint32(data[hdr+16:]))
+		set, err := parseSection(data, off)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+func parseSection(data []byte, off int) (propertySet, error) {
+	le := binary.LittleEndian
+	if off < 0 || off+8 > len(data) {
+		return nil, errPropertySet
+	}
+	section := data[off:]
+	size := int(le.Uint32(section))
+	if size < 8 || size > len(section) {
+		return nil, errPropertySet
+	}
+	section = section[:size]
+	count := int(le.Uint32(section[4:]))
+	if 8+8*count > len(section) {
+		return nil, errPropertySet
+	}
+
+	// The codepage decides how 8-bit strings are encoded, so read it first.
+	set := make(propertySet)
+	codepage := 1252
+	for i := 0; i < count; i++ {
+		id := le.Uint32(section[8+8*i:])
+		pos := int(le.Uint32(section[12+8*i:]))
+		if id == pidCodepage && pos+6 <= len(section) && le.Uint32(section[pos:]) == vtI2 {
+			codepage = int(le.Uint16(section[pos+4:]))
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		id := le.Uint32(section[8+8*i:])
+		pos := int(le.Uint32(section[12+8*i:]))
+		if pos+4 > len(section) {
+			continue
+		}
+		if id == pidDictionary {
+			set[id] = parseDictionary(section[pos:], codepage)
+			continue
+		}
+		if v := parseValue(section[pos:], codepage); v != nil {
+			set[id] = v
+		}
+	}
+	return set, nil
+}
+
+// parseValue decodes a typed property value, unsupported types return nil.
+func parseValue(b []byte, codepage int) interface{} {
+	le := binary.LittleEndian
+	typ := le.Uint32(b)
+	b = b[4:]
+	switch typ {
+	case vtI2:
+		if len(b) >= 2 {
+			return uint32(le.Uint16(b))
+		}
+	case vtI4, vtUI4:
+		if len(b) >= 4 {
+			return le.Uint32(b)
+		}
+	case vtBool:
+		if len(b) >= 2 {
+			return le.Uint16(b) != 0
+		}
+	case vtLPSTR:
+		if len(b) >= 4 {
+			n := int(le.Uint32(b))
+			if n <= len(b)-4 {
+				return decodeCodepage(b[4:4+n], codepage)
+			}
+		}
+	case vtLPWSTR:
+		if len(b) >= 4 {
+			n := 2 * int(le.Uint32(b))
+			if n <= len(b)-4 {
+				return utf16LE(b[4 : 4+n])
+			}
+		}
+	case vtFiletime:
+		if len(b) >= 8 {
+			return filetime(le.Uint64(b))
+		}
+	}
+	return nil
+}
+
+func parseDictionary(b []byte, codepage int) map[uint32]string {
+	le := binary.LittleEndian
+	names := make(map[uint32]string)
+	if len(b) < 4 {
+		return names
+	}
+	n := int(le.Uint32(b))
+	pos := 4
+	for i := 0; i < n && pos+8 <= len(b); i++ {
+		id := le.Uint32(b[pos:])
+		length := int(le.Uint32(b[pos+4:]))
+		pos += 8
+		if codepage == 1200 {
+			length *= 2
+		}
+		if length < 0 || pos+length > len(b) {
+			break
+		}
+		if codepage == 1200 {
+			names[id] = utf16LE(b[pos : pos+length])
+			// Unicode entries are padded to a multiple of 4 bytes.
+			length = (length + 3) &^ 3
+		} else {
+			names[id] = decodeCodepage(b[pos:pos+length], codepage)
+		}
+		pos += length
+	}
+	return names
+}
+
+// decodeCodepage decodes a null terminated 8-bit string. UTF-16 and UTF-8 codepages are honoured, anything else is
+// treated as Windows-1252, approximated by Latin-1.
+func decodeCodepage(b []byte, codepage int) string {
+	switch codepage {
+	case 1200:
+		return utf16LE(b)
+	case 65001:
+		return strings.TrimRight(string(b), "\x00")
+	}
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
+
+// filetime converts a Windows FILETIME, 100ns intervals since 1601-01-01, to a time.
+func filetime(v uint64) time.Time {
+	if v == 0 {
+		return time.Time{}
+	}
+	const epochDiff = 116444736000000000
+	if v < epochDiff {
+		return time.Time{}
+	}
+	v -= epochDiff
+	return time.Unix(int64(v/1e7), int64(v%1e7)*100).UTC()
+}
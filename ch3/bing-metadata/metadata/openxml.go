@@ -4,19 +4,107 @@ import (
 	"archive/zip"
 	"encoding/xml"
 	"strings"
+	"time"
 )
 
 type OfficeCoreProperty struct {
 	XMLName        xml.Name `xml:"coreProperties"`
+	Title          string   `xml:"title"`
+	Subject        string   `xml:"subject"`
 	Creator        string   `xml:"creator"`
+	Keywords       string   `xml:"keywords"`
+	Description    string   `xml:"description"`
+	Category       string   `xml:"category"`
+	ContentStatus  string   `xml:"contentStatus"`
+	Language       string   `xml:"language"`
 	LastModifiedBy string   `xml:"lastModifiedBy"`
+	Revision       string   `xml:"revision"`
+	Created        string   `xml:"created"`
+	Modified       string   `xml:"modified"`
+	LastPrinted    string   `xml:"lastPrinted"`
 }
 
 type OfficeAppProperty struct {
-	XMLName     xml.Name `xml:"Properties"`
-	Application string   `xml:"Application"`
-	Company     string   `xml:"Company"`
-	Version     string   `xml:"AppVersion"`
+	XMLName       xml.Name `xml:"Properties"`
+	Application   string   `xml:"Application"`
+	Company       string   `xml:"Company"`
+	Manager       string   `xml:"Manager"`
+	Version       string   `xml:"AppVersion"`
+	Template      string   `xml:"Template"`
+	HyperlinkBase string   `xml:"HyperlinkBase"`
+	// TotalTime is the total editing time in minutes.
+	TotalTime   int `xml:"TotalTime"`
+	Pages       int `xml:"Pages"`
+	Words       int `xml:"Words"`
+	Slides      int `xml:"Slides"`
+	DocSecurity int `xml:"DocSecurity"`
+}
+
+// OfficeCustomProperty is a user defined property from docProps/custom.xml. Value.XMLName.Local is the variant
+// type of the value, e.g. lpwstr, i4, bool or filetime.
+type OfficeCustomProperty struct {
+	Name  string `xml:"name,attr"`
+	Value struct {
+		XMLName xml.Name
+		Text    string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+type OfficeCustomProperties struct {
+	XMLName    xml.Name               `xml:"Properties"`
+	Properties []OfficeCustomProperty `xml:"property"`
+}
+
+// Map returns the custom properties keyed by name.
+func (c *OfficeCustomProperties) Map() map[string]string {
+	m := make(map[string]string, len(c.Properties))
+	for _, p := range c.Properties {
+		m[p.Name] = strings.TrimSpace(p.Value.Text)
+	}
+	return m
+}
+
+// OfficeProperties holds everything found in the docProps directory of an Office Open XML document.
+type OfficeProperties struct {
+	Core   OfficeCoreProperty
+	App    OfficeAppProperty
+	Custom OfficeCustomProperties
+}
+
+// Metadata converts the properties into the format independent result type.
+func (p *OfficeProperties) Metadata() *Metadata {
+	m := &Metadata{
+		Format:         "openxml",
+		Title:          p.Core.Title,
+		Subject:        p.Core.Subject,
+		Keywords:       p.Core.Keywords,
+		Creator:        p.Core.Creator,
+		LastModifiedBy: p.Core.LastModifiedBy,
+		Company:        p.App.Company,
+		Manager:        p.App.Manager,
+		Application:    p.App.Application,
+		AppVersion:     p.App.Version,
+		Template:       p.App.Template,
+		HyperlinkBase:  p.App.HyperlinkBase,
+		Created:        p.Core.Created,
+		Modified:       p.Core.Modified,
+		Revision:       p.Core.Revision,
+		TotalTime:      p.App.TotalTime,
+	}
+	if len(p.Custom.Properties) > 0 {
+		m.Custom = p.Custom.Map()
+	}
+	return m
+}
+
+// CreatedTime parses the W3CDTF creation timestamp.
+func (c *OfficeCoreProperty) CreatedTime() (time.Time, error) {
+	return time.Parse(time.RFC3339, strings.TrimSpace(c.Created))
+}
+
+// ModifiedTime parses the W3CDTF modification timestamp.
+func (c *OfficeCoreProperty) ModifiedTime() (time.Time, error) {
+	return time.Parse(time.RFC3339, strings.TrimSpace(c.Modified))
 }
 
 var OfficeVersions = map[string]string{
@@ -28,7 +116,12 @@ var OfficeVersions = map[string]string{
 }
 
 func (a *OfficeAppProperty) GetMajorVersion() string {
-	tokens := strings.Split(a.Version, ".")
+	return majorVersion(a.Version)
+}
+
+// majorVersion maps an AppVersion like 16.0000 to the Office release, e.g. 2016.
+func majorVersion(version string) string {
+	tokens := strings.Split(version, ".")
 
 	if len(tokens) < 2 {
 		return "Unknown"
@@ -42,24 +135,36 @@ func (a *OfficeAppProperty) GetMajorVersion() string {
 }
 
 func NewProperties(r *zip.Reader) (*OfficeCoreProperty, *OfficeAppProperty, error) {
-	var coreProps OfficeCoreProperty
-	var appProps OfficeAppProperty
+	props, err := NewOfficeProperties(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &props.Core, &props.App, nil
+}
+
+// NewOfficeProperties reads the core, app and custom properties of an Office Open XML document.
+func NewOfficeProperties(r *zip.Reader) (*OfficeProperties, error) {
+	var props OfficeProperties
 
 	for _, f := range r.File {
 		switch f.Name {
 		case "docProps/core.xml":
-			if err := process(f, &coreProps); err != nil {
-				return nil, nil, err
+			if err := process(f, &props.Core); err != nil {
+				return nil, err
 			}
 		case "docProps/app.xml":
-			if err := process(f, &appProps); err != nil {
-				return nil, nil, err
+			if err := process(f, &props.App); err != nil {
+				return nil, err
+			}
+		case "docProps/custom.xml":
+			if err := process(f, &props.Custom); err != nil {
+				return nil, err
 			}
 		default:
 			continue
 		}
 	}
-	return &coreProps, &appProps, nil
+	return &props, nil
 }
 
 func process(f *zip.File, prop interface{}) error {
@@ -0,0 +1,77 @@
+package metadata
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// docx builds an Office Open XML package holding the given files.
+func docx(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestOpenXMLExtract(t *testing.T) {
+	data := docx(t, map[string]string{
+		"[Content_Types].xml": `<?xml version="1.0"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`,
+		"docProps/core.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <dc:title>Quarterly Plan</dc:title>
+  <dc:creator>jdoe</dc:creator>
+  <cp:lastModifiedBy>asmith</cp:lastModifiedBy>
+  <cp:revision>7</cp:revision>
+  <dcterms:created xsi:type="dcterms:W3CDTF">2016-12-06T18:00:00Z</dcterms:created>
+  <dcterms:modified xsi:type="dcterms:W3CDTF">2016-12-06T18:25:32Z</dcterms:modified>
+</cp:coreProperties>`,
+		"docProps/app.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties">
+  <Template>\\fs01\templates\Corp.dotm</Template>
+  <TotalTime>42</TotalTime>
+  <Application>Microsoft Office Word</Application>
+  <Company>ACME Corp</Company>
+  <AppVersion>16.0000</AppVersion>
+</Properties>`,
+		"docProps/custom.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/custom-properties" xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes">
+  <property fmtid="{D5CDD505-2E9C-101B-9397-08002B2CF9AE}" pid="2" name="Department"><vt:lpwstr>Finance</vt:lpwstr></property>
+</Properties>`,
+	})
+	m, err := OpenXMLExtractor{}.Extract(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Metadata{
+		Format:         "openxml",
+		Title:          "Quarterly Plan",
+		Creator:        "jdoe",
+		LastModifiedBy: "asmith",
+		Company:        "ACME Corp",
+		Application:    "Microsoft Office Word",
+		AppVersion:     "16.0000",
+		Template:       `\\fs01\templates\Corp.dotm`,
+		Created:        "2016-12-06T18:00:00Z",
+		Modified:       "2016-12-06T18:25:32Z",
+		Revision:       "7",
+		TotalTime:      42,
+		Custom:         map[string]string{"Department": "Finance"},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("Extract() =\n%+v\nwant\n%+v", m, want)
+	}
+}
@@ -0,0 +1,563 @@
+package metadata
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// PDFExtractor reads the document Info dictionary and the XMP metadata stream of PDF files.
+type PDFExtractor struct{}
+
+// MaxPDFSize is the largest PDF read into memory. Harvested documents are untrusted, bigger files are rejected.
+const MaxPDFSize = 64 << 20
+
+// MaxStreamSize is the largest decoded stream, so a small zlib bomb can't exhaust memory.
+const MaxStreamSize = 16 << 20
+
+// MaxInflateTotal bounds the bytes decoded from all streams of a PDF together, so many small bombs can't keep the
+// extractor busy either.
+const MaxInflateTotal = 4 * MaxStreamSize
+
+// ErrTooLarge is returned for files or streams that exceed the size limits of an extractor.
+var ErrTooLarge = errors.New("metadata: file too large")
+
+func (PDFExtractor) Match(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("%PDF-"))
+}
+
+func (PDFExtractor) Extract(r io.ReaderAt, size int64) (*Metadata, error) {
+	if size > MaxPDFSize {
+		return nil, ErrTooLarge
+	}
+	data, err := ioutil.ReadAll(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, err
+	}
+	doc := &pdfDoc{data: data}
+
+	m := &Metadata{Format: "pdf"}
+	info, err := doc.info()
+	if err != nil {
+		return nil, err
+	}
+	m.Title = info["Title"]
+	m.Subject = info["Subject"]
+	m.Keywords = info["Keywords"]
+	m.Creator = info["Author"]
+	m.Application = info["Creator"]
+	m.Producer = info["Producer"]
+	m.Created = pdfDate(info["CreationDate"])
+	m.Modified = pdfDate(info["ModDate"])
+
+	// XMP takes precedence for fields the Info dictionary left empty.
+	if packet := doc.xmp(); packet != nil {
+		if x, err := ParseXMP(packet); err == nil {
+			fill(&m.Title, x.Get("dc:title"))
+			fill(&m.Subject, x.Get("dc:description"))
+			fill(&m.Keywords, x.Get("pdf:Keywords"))
+			fill(&m.Creator, x.Join("dc:creator"))
+			fill(&m.Application, x.Get("xmp:CreatorTool"))
+			fill(&m.Producer, x.Get("pdf:Producer"))
+			fill(&m.Created, x.Get("xmp:CreateDate"))
+			fill(&m.Modified, x.Get("xmp:ModifyDate"))
+		}
+	}
+	return m, nil
+}
+
+func fill(dst *string, v string) {
+	if *dst == "" {
+		*dst = v
+	}
+}
+
+type pdfDoc struct {
+	data []byte
+	// objects maps object numbers and generations to their bodies, built on first use.
+	objects map[[2]int][]byte
+	// endobj, stream and endstream are the sorted offsets of those keywords, found in a single scan on first use.
+	endobj, stream, endstream []int
+	// inflated counts the bytes decoded so far against MaxInflateTotal.
+	inflated int64
+}
+
+var (
+	pdfInfoRef = regexp.MustCompile(`/Info\s+(\d+)\s+(\d+)\s+R`)
+	pdfObj     = regexp.MustCompile(`(?:^|[^0-9])(\d+)\s+(\d+)\s+obj\b`)
+	pdfStream  = regexp.MustCompile(`stream\r?\n`)
+	pdfObjStm  = regexp.MustCompile(`/Type\s*/ObjStm`)
+	pdfFirst   = regexp.MustCompile(`/First\s+(\d+)`)
+)
+
+// info resolves the Info dictionary referenced by the last trailer, which wins in incrementally updated files.
+func (d *pdfDoc) info() (map[string]string, error) {
+	refs := pdfInfoRef.FindAllSubmatch(d.data, -1)
+	if len(refs) == 0 {
+		return map[string]string{}, nil
+	}
+	ref := refs[len(refs)-1]
+	num, _ := strconv.Atoi(string(ref[1]))
+	gen, _ := strconv.Atoi(string(ref[2]))
+
+	body := d.object(num, gen)
+	if body == nil {
+		return nil, fmt.Errorf("metadata: pdf info object %d %d not found", num, gen)
+	}
+
+	p := &pdfParser{data: body}
+	dict, ok := p.value().(pdfDict)
+	if !ok {
+		return nil, fmt.Errorf("metadata: pdf info object %d %d is not a dictionary", num, gen)
+	}
+
+	info := make(map[string]string, len(dict))
+	for k, v := range dict {
+		// Values may themselves be indirect references to string objects.
+		if ref, ok := v.(pdfRef); ok {
+			v = (&pdfParser{data: d.object(ref.num, ref.gen)}).value()
+		}
+		if s, ok := v.(string); ok {
+			info[k] = s
+		}
+	}
+	return info, nil
+}
+
+// object returns the body of an indirect object, looking into compressed object streams if the object isn't
+// stored directly in the file.
+func (d *pdfDoc) object(num, gen int) []byte {
+	if d.objects == nil {
+		d.index()
+	}
+	return d.objects[[2]int{num, gen}]
+}
+
+// index collects every object of the file in one pass. Later definitions win, as in incrementally updated files,
+// and objects stored directly in the file win over copies in object streams.
+func (d *pdfDoc) index() {
+	d.objects = make(map[[2]int][]byte)
+	if d.endobj == nil {
+		d.endobj = offsets(d.data, []byte("endobj"))
+	}
+	for _, m := range pdfObj.FindAllSubmatchIndex(d.data, -1) {
+		num, err1 := strconv.Atoi(string(d.data[m[2]:m[3]]))
+		gen, err2 := strconv.Atoi(string(d.data[m[4]:m[5]]))
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		end := next(d.endobj, m[1])
+		if end < 0 {
+			continue
+		}
+		d.objects[[2]int{num, gen}] = d.data[m[1]:end]
+	}
+
+	objStms := pdfObjStm.FindAllIndex(d.data, -1)
+	if len(objStms) == 0 {
+		return
+	}
+	objs := offsets(d.data, []byte("obj"))
+	d.scanStreams()
+	for _, loc := range objStms {
+		// /First may appear anywhere in the stream dictionary, before or after /Type.
+		i := sort.SearchInts(objs, loc[0]) - 1
+		dictEnd := next(d.stream, loc[1])
+		if i < 0 || dictEnd < 0 {
+			continue
+		}
+		first := pdfFirst.FindSubmatch(d.data[objs[i]:dictEnd])
+		stream := d.streamAfter(loc[1])
+		if first == nil || stream == nil {
+			continue
+		}
+		offset, _ := strconv.Atoi(string(first[1]))
+		for num, body := range objectsFromStream(stream, offset) {
+			// Objects in streams always have generation 0.
+			key := [2]int{num, 0}
+			if _, ok := d.objects[key]; !ok {
+				d.objects[key] = body
+			}
+		}
+	}
+}
+
+// objectsFromStream splits a decoded object stream, whose header lists "num offset" pairs relative to first.
+// Entries with offsets outside the stream or out of order are skipped, the input is untrusted.
+func objectsFromStream(stream []byte, first int) map[int][]byte {
+	if first < 0 || first > len(stream) {
+		return nil
+	}
+	header := strings.Fields(string(stream[:first]))
+	objects := make(map[int][]byte)
+	for i := 0; i+1 < len(header); i += 2 {
+		num, err1 := strconv.Atoi(header[i])
+		off, err2 := strconv.Atoi(header[i+1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		start := first + off
+		end := len(stream)
+		if i+3 < len(header) {
+			if next, err := strconv.Atoi(header[i+3]); err == nil && first+next <= len(stream) {
+				end = first + next
+			}
+		}
+		if off < 0 || start < first || start > end || end > len(stream) {
+			continue
+		}
+		objects[num] = stream[start:end]
+	}
+	return objects
+}
+
+// streamAfter decodes the first stream starting after offset, inflating it if it is Flate compressed and the
+// document hasn't used up MaxInflateTotal yet.
+func (d *pdfDoc) streamAfter(offset int) []byte {
+	d.scanStreams()
+	keyword := next(d.stream, offset)
+	if keyword < 0 {
+		return nil
+	}
+	// The keyword is followed by CRLF or LF.
+	start := keyword + len("stream")
+	if bytes.HasPrefix(d.data[start:], []byte("\r")) {
+		start++
+	}
+	if !bytes.HasPrefix(d.data[start:], []byte("\n")) {
+		return nil
+	}
+	start++
+	end := next(d.endstream, start)
+	if end < 0 {
+		return nil
+	}
+	raw := d.data[start:end]
+
+	max := int64(MaxInflateTotal) - d.inflated
+	if max > MaxStreamSize {
+		max = MaxStreamSize
+	}
+	if max <= 0 {
+		return raw
+	}
+	inflated, err := inflate(raw, max)
+	if errors.Is(err, ErrTooLarge) {
+		d.inflated += max
+	}
+	if err != nil {
+		return raw
+	}
+	d.inflated += int64(len(inflated))
+	return inflated
+}
+
+func (d *pdfDoc) scanStreams() {
+	if d.stream == nil {
+		d.stream = offsets(d.data, []byte("stream"))
+		d.endstream = offsets(d.data, []byte("endstream"))
+	}
+}
+
+// offsets returns the offsets of every occurrence of sep in data, in ascending order.
+func offsets(data, sep []byte) []int {
+	list := []int{}
+	for i := 0; ; {
+		j := bytes.Index(data[i:], sep)
+		if j < 0 {
+			return list
+		}
+		list = append(list, i+j)
+		i += j + len(sep)
+	}
+}
+
+// next returns the first of the sorted offsets that isn't before offset, or -1.
+func next(offsets []int, offset int) int {
+	i := sort.SearchInts(offsets, offset)
+	if i == len(offsets) {
+		return -1
+	}
+	return offsets[i]
+}
+
+// inflate decompresses zlib data, failing with ErrTooLarge if the output exceeds max bytes. Truncated input
+// returns what could be decoded.
+func inflate(raw []byte, max int64) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := ioutil.ReadAll(io.LimitReader(zr, max+1))
+	if int64(len(out)) > max {
+		return nil, ErrTooLarge
+	}
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+// xmp returns the XMP packet of the document. Metadata streams are usually stored uncompressed, otherwise the
+// compressed streams are searched until the first packet is found or MaxInflateTotal is used up.
+func (d *pdfDoc) xmp() []byte {
+	if packet := FindXMP(d.data); packet != nil {
+		return packet
+	}
+	for _, loc := range pdfStream.FindAllIndex(d.data, -1) {
+		if d.inflated >= MaxInflateTotal {
+			return nil
+		}
+		if stream := d.streamAfter(loc[0]); stream != nil {
+			if packet := FindXMP(stream); packet != nil {
+				return packet
+			}
+		}
+	}
+	return nil
+}
+
+// pdfDate converts a PDF date, D:YYYYMMDDHHmmSSOHH'mm', to RFC 3339. Unparsable values are returned as is.
+func pdfDate(s string) string {
+	v := strings.TrimPrefix(strings.TrimSpace(s), "D:")
+	if len(v) < 4 {
+		return s
+	}
+	v = strings.Replace(strings.TrimSuffix(v, "'"), "'", ":", 1)
+
+	layouts := []string{"20060102150405Z07:00", "20060102150405Z0700", "20060102150405Z07", "20060102150405", "200601021504", "20060102", "2006"}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	// Z00'00' style offsets after a Z.
+	if i := strings.IndexByte(v, 'Z'); i == 14 {
+		if t, err := time.Parse("20060102150405", v[:14]); err == nil {
+			return t.UTC().Format(time.RFC3339)
+		}
+	}
+	return s
+}
+
+type pdfDict map[string]interface{}
+
+type pdfName string
+
+type pdfKeyword string
+
+type pdfRef struct {
+	num, gen int
+}
+
+// pdfParser is a minimal tokenizer for PDF objects, just enough to read dictionaries of strings.
+type pdfParser struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelim(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (p *pdfParser) skip() {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if isPDFSpace(c) {
+			p.pos++
+		} else if c == '%' {
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+		} else {
+			return
+		}
+	}
+}
+
+// value parses the next object. Strings are returned as string, so names, numbers and keywords get their own
+// types to keep them apart.
+func (p *pdfParser) value() interface{} {
+	p.skip()
+	if p.pos >= len(p.data) {
+		return nil
+	}
+
+	switch c := p.data[p.pos]; {
+	case c == '<' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '<':
+		p.pos += 2
+		return p.dict()
+	case c == '<':
+		return p.hexString()
+	case c == '(':
+		return p.literalString()
+	case c == '[':
+		p.pos++
+		var arr []interface{}
+		for {
+			p.skip()
+			if p.pos >= len(p.data) || p.data[p.pos] == ']' {
+				p.pos++
+				return arr
+			}
+			arr = append(arr, p.value())
+		}
+	case c == '/':
+		p.pos++
+		return pdfName("/" + p.token())
+	default:
+		tok := p.token()
+		if tok == "" {
+			p.pos++
+			return nil
+		}
+		// Look ahead for an indirect reference, "num gen R".
+		if num, err := strconv.Atoi(tok); err == nil {
+			save := p.pos
+			p.skip()
+			gen, err := strconv.Atoi(p.token())
+			p.skip()
+			if err == nil && p.token() == "R" {
+				return pdfRef{num, gen}
+			}
+			p.pos = save
+		}
+		return pdfKeyword(tok)
+	}
+}
+
+func (p *pdfParser) token() string {
+	start := p.pos
+	for p.pos < len(p.data) && !isPDFSpace(p.data[p.pos]) && !isPDFDelim(p.data[p.pos]) {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+func (p *pdfParser) dict() pdfDict {
+	d := make(pdfDict)
+	for {
+		p.skip()
+		if p.pos >= len(p.data) {
+			return d
+		}
+		if p.data[p.pos] == '>' {
+			p.pos += 2
+			return d
+		}
+		key, ok := p.value().(pdfName)
+		if !ok {
+			return d
+		}
+		d[strings.TrimPrefix(string(key), "/")] = p.value()
+	}
+}
+
+func (p *pdfParser) literalString() string {
+	p.pos++
+	var buf []byte
+	depth := 1
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return decodePDFText(buf)
+			}
+		case '\\':
+			if p.pos >= len(p.data) {
+				break
+			}
+			e := p.data[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// Line continuation.
+				if e == '\r' && p.pos < len(p.data) && p.data[p.pos] == '\n' {
+					p.pos++
+				}
+				continue
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				v := int(e - '0')
+				for i := 0; i < 2 && p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '7'; i++ {
+					v = v*8 + int(p.data[p.pos]-'0')
+					p.pos++
+				}
+				c = byte(v)
+			default:
+				c = e
+			}
+		}
+		buf = append(buf, c)
+	}
+	return decodePDFText(buf)
+}
+
+func (p *pdfParser) hexString() string {
+	p.pos++
+	var digits []byte
+	for p.pos < len(p.data) && p.data[p.pos] != '>' {
+		if c := p.data[p.pos]; !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+		p.pos++
+	}
+	p.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	buf := make([]byte, len(digits)/2)
+	for i := range buf {
+		v, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		buf[i] = byte(v)
+	}
+	return decodePDFText(buf)
+}
+
+// decodePDFText decodes a PDF text string, which is UTF-16BE with a byte order mark or PDFDocEncoding, treated
+// as Latin-1 here.
+func decodePDFText(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+		u := make([]uint16, 0, (len(b)-2)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(u))
+	}
+	if len(b) >= 3 && b[0] == 0xef && b[1] == 0xbb && b[2] == 0xbf {
+		return string(b[3:])
+	}
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
@@ -0,0 +1,169 @@
+package metadata
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func extractFile(t *testing.T, name string) (*Metadata, error) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Extract(bytes.NewReader(data), int64(len(data)))
+}
+
+func TestPDFExtract(t *testing.T) {
+	tests := []struct {
+		file string
+		want *Metadata
+	}{
+		{
+			file: "info-xmp.pdf",
+			want: &Metadata{
+				Format:      "pdf",
+				Title:       "Ünicode Title",
+				Keywords:    "internal, q3",
+				Creator:     "John (JD) Doe",
+				Application: "Microsoft® Word 2016",
+				Producer:    "Acrobat Distiller",
+				Created:     "2019-01-02T03:04:05+01:00",
+				Modified:    "2019-01-02T03:04:05Z",
+			},
+		},
+		{
+			file: "objstm.pdf",
+			want: &Metadata{Format: "pdf", Creator: "Objstm Author", Producer: "LibreOffice 7.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			m, err := extractFile(t, tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(m, tt.want) {
+				t.Errorf("got %+v, want %+v", m, tt.want)
+			}
+		})
+	}
+}
+
+func TestPDFExtractCorrupt(t *testing.T) {
+	for _, file := range []string{"corrupt-objstm.pdf", "truncated.pdf"} {
+		t.Run(file, func(t *testing.T) {
+			if _, err := extractFile(t, file); err == nil {
+				t.Error("expected an error for a missing info object")
+			}
+		})
+	}
+}
+
+func TestPDFMatch(t *testing.T) {
+	var e PDFExtractor
+	if !e.Match([]byte("%PDF-1.7\n")) {
+		t.Error("header at the start not matched")
+	}
+	if e.Match([]byte("junk before %PDF-1.7\n")) {
+		t.Error("header after other content matched")
+	}
+}
+
+func TestPDFTooLarge(t *testing.T) {
+	_, err := PDFExtractor{}.Extract(bytes.NewReader([]byte("%PDF-1.4")), MaxPDFSize+1)
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("got %v, want ErrTooLarge", err)
+	}
+}
+
+func TestObjectsFromStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream string
+		first  int
+		want   map[int]string
+	}{
+		{"valid", "1 0 2 5 (one)(two)", 8, map[int]string{1: "(one)", 2: "(two)"}},
+		{"negative offset", "1 -5 (one)", 8, map[int]string{}},
+		{"offset past end", "1 99 (one)", 8, map[int]string{}},
+		{"out of order", "1 4 2 0 (one)(two)", 8, map[int]string{2: "(one)(two)"}},
+		{"first past end", "1 0", 50, nil},
+		{"negative first", "1 0", -1, nil},
+		{"garbage header", "x y 1 0 (one)", 8, map[int]string{1: "(one)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := objectsFromStream([]byte(tt.stream), tt.first)
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("got %q, want nil", got)
+				}
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+			for num, body := range tt.want {
+				if string(got[num]) != body {
+					t.Errorf("object %d: got %q, want %q", num, got[num], body)
+				}
+			}
+		})
+	}
+}
+
+func TestInflateLimit(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write([]byte(strings.Repeat("A", 1<<20)))
+	zw.Close()
+
+	if _, err := inflate(buf.Bytes(), 1024); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("got %v, want ErrTooLarge", err)
+	}
+	out, err := inflate(buf.Bytes(), 1<<20)
+	if err != nil || len(out) != 1<<20 {
+		t.Errorf("got %d bytes, %v", len(out), err)
+	}
+}
+
+func TestPDFManyObjectHeaders(t *testing.T) {
+	// Object headers without endobj used to rescan the rest of the file for each header.
+	data := []byte("%PDF-1.4\n" + strings.Repeat("1 0 obj\n<< /Type /ObjStm >>\n", 50000) + "trailer << /Info 1 0 R >>")
+	start := time.Now()
+	if _, err := Extract(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("Extract() succeeded without an info object")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Extract() took %s", d)
+	}
+}
+
+func TestPDFInflateBudget(t *testing.T) {
+	var bomb bytes.Buffer
+	zw := zlib.NewWriter(&bomb)
+	zw.Write(make([]byte, MaxStreamSize))
+	zw.Close()
+
+	var data bytes.Buffer
+	data.WriteString("%PDF-1.4\n")
+	for i := 1; i <= 8; i++ {
+		fmt.Fprintf(&data, "%d 0 obj\n<< /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n", i, bomb.Bytes())
+	}
+	d := &pdfDoc{data: data.Bytes()}
+	if packet := d.xmp(); packet != nil {
+		t.Errorf("found XMP packet %q", packet)
+	}
+	if d.inflated > MaxInflateTotal {
+		t.Errorf("inflated %d bytes, limit is %d", d.inflated, MaxInflateTotal)
+	}
+}
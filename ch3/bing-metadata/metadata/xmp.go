@@ -0,0 +1,119 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// XMPPrefixes maps the namespaces commonly found in XMP packets to their usual prefixes.
+var XMPPrefixes = map[string]string{
+	"http://purl.org/dc/elements/1.1/":            "dc",
+	"http://ns.adobe.com/xap/1.0/":                "xmp",
+	"http://ns.adobe.com/xap/1.0/mm/":             "xmpMM",
+	"http://ns.adobe.com/xap/1.0/rights/":         "xmpRights",
+	"http://ns.adobe.com/pdf/1.3/":                "pdf",
+	"http://ns.adobe.com/photoshop/1.0/":          "photoshop",
+	"http://ns.adobe.com/tiff/1.0/":               "tiff",
+	"http://ns.adobe.com/exif/1.0/":               "exif",
+	"http://ns.adobe.com/exif/1.0/aux/":           "aux",
+	"http://ns.microsoft.com/photo/1.0/":          "MicrosoftPhoto",
+	"http://www.w3.org/1999/02/22-rdf-syntax-ns#": "rdf",
+}
+
+const rdfNS = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+
+// XMP holds the simple properties of an XMP packet keyed by prefix:name, e.g. "dc:creator". Array values keep
+// their order. Structured properties such as xmpMM:History are skipped.
+type XMP map[string][]string
+
+// Get returns the first value of a property.
+func (x XMP) Get(key string) string {
+	if v := x[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// Join returns all values of a property separated by "; ".
+func (x XMP) Join(key string) string {
+	return strings.Join(x[key], "; ")
+}
+
+func xmpKey(name xml.Name) string {
+	prefix, ok := XMPPrefixes[name.Space]
+	if !ok {
+		prefix = name.Space
+	}
+	return prefix + ":" + name.Local
+}
+
+// FindXMP returns the first XMP packet embedded in data, or nil.
+func FindXMP(data []byte) []byte {
+	start := bytes.Index(data, []byte("<x:xmpmeta"))
+	if start < 0 {
+		return nil
+	}
+	end := bytes.Index(data[start:], []byte("</x:xmpmeta>"))
+	if end < 0 {
+		return nil
+	}
+	return data[start : start+end+len("</x:xmpmeta>")]
+}
+
+func ParseXMP(data []byte) (XMP, error) {
+	x := make(XMP)
+	var stack []xml.Name
+	parent := func(n int) xml.Name {
+		if len(stack) < n {
+			return xml.Name{}
+		}
+		return stack[len(stack)-n]
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return x, nil
+		}
+		if err != nil {
+			return x, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			// Simple properties are often written as attributes of rdf:Description.
+			if t.Name.Space == rdfNS && t.Name.Local == "Description" {
+				for _, a := range t.Attr {
+					if a.Name.Space == rdfNS || a.Name.Space == "xmlns" || a.Name.Space == "" {
+						continue
+					}
+					x[xmpKey(a.Name)] = append(x[xmpKey(a.Name)], a.Value)
+				}
+			}
+			stack = append(stack, t.Name)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			text := strings.TrimSpace(string(t))
+			if text == "" {
+				continue
+			}
+			var prop xml.Name
+			switch {
+			case parent(1).Space == rdfNS && parent(1).Local == "li" && parent(4).Local == "Description":
+				prop = parent(3)
+			case parent(1).Space != rdfNS && parent(2).Space == rdfNS && parent(2).Local == "Description":
+				prop = parent(1)
+			default:
+				continue
+			}
+			x[xmpKey(prop)] = append(x[xmpKey(prop)], text)
+		}
+	}
+}
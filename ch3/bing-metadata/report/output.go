@@ -0,0 +1,82 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/bilalcaliskan/blackhat-go/ch3/bing-metadata/metadata"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// CSVHeader are the columns written by WriteCSV.
+var CSVHeader = []string{
+	"path", "url", "format", "title", "creator", "last_modified_by", "company", "manager", "application",
+	"app_version", "producer", "template", "created", "modified", "revision", "total_time", "error",
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes one row per document. Artifacts and custom properties only appear in the JSON report.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, e := range r.Entries {
+		m := e.Metadata
+		if m == nil {
+			m = &metadata.Metadata{}
+		}
+		var totalTime string
+		if m.TotalTime > 0 {
+			totalTime = strconv.Itoa(m.TotalTime)
+		}
+		row := []string{
+			e.Path, e.URL, m.Format, m.Title, m.Creator, m.LastModifiedBy, m.Company, m.Manager, m.Application,
+			m.AppVersion, m.Producer, m.Template, m.Created, m.Modified, m.Revision, totalTime, e.Err,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSummary writes the summary as plain text tables.
+func (r *Report) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "%d documents\n", r.Summary.Documents)
+	for _, section := range []struct {
+		title  string
+		counts []Count
+	}{
+		{"Creators", r.Summary.Creators},
+		{"Last modified by", r.Summary.LastModifiedBy},
+		{"Companies", r.Summary.Companies},
+		{"Application versions", r.Summary.AppVersions},
+		{"Users", r.Summary.Users},
+	} {
+		fmt.Fprintf(tw, "\n%s (%d)\n", section.title, len(section.counts))
+		for _, c := range section.counts {
+			fmt.Fprintf(tw, "%d\t%s\n", c.Count, c.Value)
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteUsers writes the unique user names, one per line.
+func (r *Report) WriteUsers(w io.Writer) error {
+	for _, c := range r.Summary.Users {
+		if _, err := fmt.Fprintln(w, c.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
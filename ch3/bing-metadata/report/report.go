@@ -0,0 +1,184 @@
+package report
+
+import (
+	"errors"
+	"github.com/bilalcaliskan/blackhat-go/ch3/bing-metadata/metadata"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Entry is the metadata of one document. URL is only set for downloaded documents.
+type Entry struct {
+	Path     string             `json:"path"`
+	URL      string             `json:"url,omitempty"`
+	Metadata *metadata.Metadata `json:"metadata,omitempty"`
+	Err      string             `json:"error,omitempty"`
+}
+
+// Count is a unique value and the number of documents it was found in.
+type Count struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Summary lists the unique values of the fields that identify people and software. Users merges creators,
+// last-modified-by users, comment and revision authors and image artists, ready to be used as a username list.
+type Summary struct {
+	Documents      int     `json:"documents"`
+	Creators       []Count `json:"creators"`
+	LastModifiedBy []Count `json:"last_modified_by"`
+	Companies      []Count `json:"companies"`
+	AppVersions    []Count `json:"app_versions"`
+	Users          []Count `json:"users"`
+}
+
+type Report struct {
+	Entries []Entry `json:"entries"`
+	Summary Summary `json:"summary"`
+}
+
+// File extracts the metadata of the document at path.
+func File(path string) Entry {
+	e, _ := file(path)
+	return e
+}
+
+// file is File that also returns the error recorded in the entry.
+func file(path string) (Entry, error) {
+	e := Entry{Path: path}
+	f, err := os.Open(path)
+	if err != nil {
+		e.Err = err.Error()
+		return e, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		e.Err = err.Error()
+		return e, err
+	}
+	if e.Metadata, err = metadata.Extract(f, st.Size()); err != nil {
+		e.Err = err.Error()
+	}
+	return e, err
+}
+
+// Walk extracts the metadata of every supported file below dir. Files no extractor recognizes are skipped, files
+// and directories that can't be read are recorded with their error and the walk goes on. Only a dir that can't be
+// read at all fails the walk.
+func Walk(dir string) ([]Entry, error) {
+	var entries []Entry
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == dir && info == nil {
+				return err
+			}
+			entries = append(entries, Entry{Path: path, Err: err.Error()})
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		e, err := file(path)
+		if errors.Is(err, metadata.ErrUnsupported) {
+			return nil
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	return entries, err
+}
+
+func New(entries []Entry) *Report {
+	return &Report{Entries: entries, Summary: summarize(entries)}
+}
+
+func summarize(entries []Entry) Summary {
+	creators := newCounter()
+	modifiers := newCounter()
+	companies := newCounter()
+	versions := newCounter()
+	users := newCounter()
+
+	var docs int
+	for _, e := range entries {
+		m := e.Metadata
+		if m == nil {
+			continue
+		}
+		docs++
+		creators.add(m.Creator)
+		modifiers.add(m.LastModifiedBy)
+		companies.add(m.Company)
+		if m.Application != "" || m.AppVersion != "" {
+			versions.add(strings.TrimSpace(m.Application + " " + m.AppVersion))
+		}
+
+		users.add(people(m)...)
+	}
+
+	return Summary{
+		Documents:      docs,
+		Creators:       creators.sorted(),
+		LastModifiedBy: modifiers.sorted(),
+		Companies:      companies.sorted(),
+		AppVersions:    versions.sorted(),
+		Users:          users.sorted(),
+	}
+}
+
+// people returns every name found in a document.
+func people(m *metadata.Metadata) []string {
+	names := []string{m.Creator, m.LastModifiedBy}
+	if m.EXIF != nil {
+		names = append(names, m.EXIF.Artist)
+	}
+	for _, a := range m.Artifacts {
+		switch {
+		case a.Kind == metadata.ArtifactCommentAuthor, a.Kind == metadata.ArtifactRevisionAuthor:
+			names = append(names, a.Value)
+		case a.Kind == metadata.ArtifactImageEXIF && a.Detail == "Artist":
+			names = append(names, a.Value)
+		}
+	}
+	return names
+}
+
+// counter counts unique values. add is called once per document, so each value is counted at most once per
+// document.
+type counter struct {
+	counts map[string]int
+}
+
+func newCounter() *counter {
+	return &counter{counts: make(map[string]int)}
+}
+
+func (c *counter) add(values ...string) {
+	seen := make(map[string]bool)
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		c.counts[v]++
+	}
+}
+
+// sorted returns the values by descending count, ties ordered alphabetically.
+func (c *counter) sorted() []Count {
+	ret := make([]Count, 0, len(c.counts))
+	for v, n := range c.counts {
+		ret = append(ret, Count{v, n})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Count != ret[j].Count {
+			return ret[i].Count > ret[j].Count
+		}
+		return ret[i].Value < ret[j].Value
+	})
+	return ret
+}
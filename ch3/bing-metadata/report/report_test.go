@@ -0,0 +1,100 @@
+package report
+
+import (
+	"github.com/bilalcaliskan/blackhat-go/ch3/bing-metadata/metadata"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func copyFixture(t *testing.T, name, dst string) {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join("..", "metadata", "testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(dst, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	copyFixture(t, "info-xmp.pdf", filepath.Join(dir, "report.pdf"))
+	copyFixture(t, "truncated.pdf", filepath.Join(dir, "sub", "broken.pdf"))
+	if err := ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("plain text"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Walk(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]Entry{}
+	for _, e := range entries {
+		byName[filepath.Base(e.Path)] = e
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want the 2 PDFs: %+v", len(entries), entries)
+	}
+	if e := byName["report.pdf"]; e.Err != "" || e.Metadata == nil || e.Metadata.Creator != "John (JD) Doe" {
+		t.Errorf("report.pdf: %+v", e)
+	}
+	// A broken file is reported without stopping the walk.
+	if e := byName["broken.pdf"]; e.Err == "" {
+		t.Errorf("broken.pdf: %+v, want an error", e)
+	}
+}
+
+func TestWalkMissingDir(t *testing.T) {
+	if _, err := Walk(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+func TestSummary(t *testing.T) {
+	entries := []Entry{
+		{Path: "a.docx", Metadata: &metadata.Metadata{
+			Creator:        "jdoe",
+			LastModifiedBy: "jdoe",
+			Company:        "ACME Corp",
+			Application:    "Microsoft Office Word",
+			AppVersion:     "16.0000",
+			Artifacts: []metadata.Artifact{
+				{Kind: metadata.ArtifactCommentAuthor, Value: "asmith"},
+				{Kind: metadata.ArtifactRevisionAuthor, Value: "asmith"},
+				{Kind: metadata.ArtifactRevisionAuthor, Value: " jdoe "},
+				{Kind: metadata.ArtifactTemplate, Value: `\\fs01\templates\Corp.dotm`},
+			},
+		}},
+		{Path: "b.docx", Metadata: &metadata.Metadata{
+			Creator:        "asmith",
+			LastModifiedBy: "bwayne",
+			Company:        "ACME Corp",
+			Application:    "Microsoft Office Word",
+			AppVersion:     "16.0000",
+		}},
+		{Path: "c.jpg", Metadata: &metadata.Metadata{
+			EXIF: &metadata.EXIF{Artist: "photographer"},
+		}},
+		{Path: "d.pdf", Err: "corrupt"},
+	}
+
+	want := Summary{
+		Documents:      3,
+		Creators:       []Count{{"asmith", 1}, {"jdoe", 1}},
+		LastModifiedBy: []Count{{"bwayne", 1}, {"jdoe", 1}},
+		Companies:      []Count{{"ACME Corp", 2}},
+		AppVersions:    []Count{{"Microsoft Office Word 16.0000", 2}},
+		// asmith and jdoe appear several times in a.docx but count once per document.
+		Users: []Count{{"asmith", 2}, {"bwayne", 1}, {"jdoe", 1}, {"photographer", 1}},
+	}
+	if got := New(entries).Summary; !reflect.DeepEqual(got, want) {
+		t.Errorf("Summary =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// BingURL is the search page scraped by BingHTML.
+const BingURL = "https://www.bing.com/search"
+
+// BingResultSelector matches the result links on a Bing search page.
+const BingResultSelector = "#b_results li.b_algo h2 a"
+
+// BingHTML scrapes Bing's HTML search results. Bing serves an empty page to unknown clients, so a browser
+// User-Agent is sent.
+type BingHTML struct {
+	URL       string
+	UserAgent string
+	// Pages is the number of result pages to scrape, Bing returns about 10 results per page.
+	Pages  int
+	Client *http.Client
+}
+
+func NewBingHTML() *BingHTML {
+	return &BingHTML{
+		URL:       BingURL,
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+		Pages:     1,
+		Client:    http.DefaultClient,
+	}
+}
+
+func (b *BingHTML) Search(domain, filetype string) ([]string, error) {
+	var urls []string
+	for page := 0; page < b.Pages; page++ {
+		params := url.Values{"q": {Query(domain, filetype)}}
+		if page > 0 {
+			params.Set("first", fmt.Sprint(page*10+1))
+		}
+
+		req, err := http.NewRequest(http.MethodGet, b.URL+"?"+params.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", b.UserAgent)
+		res, err := b.Client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return nil, fmt.Errorf("bing answered %s", res.Status)
+		}
+
+		found, err := ParseBingHTML(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			break
+		}
+		urls = append(urls, found...)
+	}
+	return dedupe(urls), nil
+}
+
+// ParseBingHTML extracts the result URLs from a Bing search page, e.g. one saved from a browser.
+func ParseBingHTML(r io.Reader) ([]string, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	doc.Find(BingResultSelector).Each(func(i int, s *goquery.Selection) {
+		href, ok := s.Attr("href")
+		if !ok {
+			return
+		}
+		urls = append(urls, unwrapBingURL(href))
+	})
+	return urls, nil
+}
+
+// unwrapBingURL resolves Bing's click tracking links, https://www.bing.com/ck/a?...&u=a1<base64 url>, to the
+// target URL. Other links are returned unchanged.
+func unwrapBingURL(href string) string {
+	u, err := url.Parse(href)
+	if err != nil || !strings.HasSuffix(u.Host, "bing.com") || u.Path != "/ck/a" {
+		return href
+	}
+	target := u.Query().Get("u")
+	if !strings.HasPrefix(target, "a1") {
+		return href
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(target[2:], "="))
+	if err != nil {
+		return href
+	}
+	return string(decoded)
+}
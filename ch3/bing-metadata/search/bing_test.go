@@ -0,0 +1,148 @@
+package search
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func openFixture(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestParseBingHTML(t *testing.T) {
+	urls, err := ParseBingHTML(openFixture(t, "results.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"https://example.com/docs/annual-report-2019.pdf",
+		"https://example.com/files/q3%20results.pdf",
+		"https://example.com/docs/annual-report-2019.pdf",
+		"https://www.bing.com/ck/a?u=a1%%%invalid",
+	}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("got %q, want %q", urls, want)
+	}
+
+	urls, err = ParseBingHTML(openFixture(t, "empty.html"))
+	if err != nil || len(urls) != 0 {
+		t.Errorf("empty page: got %q, %v", urls, err)
+	}
+}
+
+func TestBingHTMLSearch(t *testing.T) {
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.UserAgent(), "Mozilla/") {
+			t.Errorf("User-Agent %q is not a browser's", r.UserAgent())
+		}
+		pages = append(pages, r.URL.Query().Get("first"))
+		fixture := "results.html"
+		if r.URL.Query().Get("first") == "21" {
+			fixture = "empty.html"
+		}
+		http.ServeFile(w, r, filepath.Join("testdata", fixture))
+	}))
+	defer srv.Close()
+
+	b := NewBingHTML()
+	b.URL = srv.URL
+	b.Pages = 5
+	urls, err := b.Search("example.com", "pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 3 {
+		t.Errorf("got %q, want the 3 unique results", urls)
+	}
+	// Scraping stops at the first page without results.
+	if want := []string{"", "11", "21"}; !reflect.DeepEqual(pages, want) {
+		t.Errorf("requested pages %q, want %q", pages, want)
+	}
+}
+
+func TestBingHTMLStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "captcha", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	b := NewBingHTML()
+	b.URL = srv.URL
+	if _, err := b.Search("example.com", "pdf"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("got %v, want the status in the error", err)
+	}
+}
+
+func TestBingAPI(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "results",
+			status: http.StatusOK,
+			body:   `{"webPages": {"value": [{"url": "https://example.com/a.pdf"}, {"url": "https://example.com/b.pdf"}, {"url": "https://example.com/a.pdf"}]}}`,
+			want:   []string{"https://example.com/a.pdf", "https://example.com/b.pdf"},
+		},
+		{
+			name:    "JSON error",
+			status:  http.StatusUnauthorized,
+			body:    `{"error": {"code": "401", "message": "Access denied due to invalid subscription key."}}`,
+			wantErr: "401 Unauthorized: Access denied",
+		},
+		{
+			name:    "HTML error",
+			status:  http.StatusBadGateway,
+			body:    "<html><body>Bad Gateway</body></html>",
+			wantErr: "502 Bad Gateway: <html>",
+		},
+		{
+			name:    "empty error",
+			status:  http.StatusServiceUnavailable,
+			wantErr: "503 Service Unavailable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Ocp-Apim-Subscription-Key") != "secret" {
+					t.Errorf("subscription key missing")
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			b := NewBingAPI("secret")
+			b.URL = srv.URL
+			urls, err := b.Search("example.com", "pdf")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(urls, tt.want) {
+				t.Errorf("got %q, want %q", urls, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// BingAPIURL is the endpoint of the Bing Web Search API v7.
+const BingAPIURL = "https://api.bing.microsoft.com/v7.0/search"
+
+// BingAPI uses the Bing Web Search API, which keeps working when the HTML layout changes.
+type BingAPI struct {
+	URL    string
+	Key    string
+	Count  int
+	Client *http.Client
+}
+
+type bingAPIRes struct {
+	WebPages struct {
+		Value []struct {
+			URL string `json:"url"`
+		} `json:"value"`
+	} `json:"webPages"`
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func NewBingAPI(key string) *BingAPI {
+	return &BingAPI{
+		URL:    BingAPIURL,
+		Key:    key,
+		Count:  50,
+		Client: http.DefaultClient,
+	}
+}
+
+func (b *BingAPI) Search(domain, filetype string) ([]string, error) {
+	params := url.Values{
+		"q":              {Query(domain, filetype)},
+		"count":          {strconv.Itoa(b.Count)},
+		"responseFilter": {"Webpages"},
+	}
+	req, err := http.NewRequest(http.MethodGet, b.URL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Ocp-Apim-Subscription-Key", b.Key)
+
+	res, err := b.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, apiError(res)
+	}
+	var ret bingAPIRes
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	for _, v := range ret.WebPages.Value {
+		urls = append(urls, v.URL)
+	}
+	return dedupe(urls), nil
+}
+
+// apiError describes a failed call using the error object of the response, or the start of the body if it isn't
+// JSON, e.g. an HTML error page from a proxy.
+func apiError(res *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 512))
+	var ret bingAPIRes
+	if err := json.Unmarshal(body, &ret); err == nil && ret.Error.Message != "" {
+		return fmt.Errorf("bing api answered %s: %s", res.Status, ret.Error.Message)
+	}
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		return fmt.Errorf("bing api answered %s: %s", res.Status, msg)
+	}
+	return fmt.Errorf("bing api answered %s", res.Status)
+}
@@ -0,0 +1,34 @@
+package search
+
+import (
+	"fmt"
+)
+
+// SearchProvider finds the URLs of documents of a given file type hosted on a domain.
+type SearchProvider interface {
+	Search(domain, filetype string) ([]string, error)
+}
+
+// Query builds the Bing query used by the HTML and API providers, e.g.
+// site:nytimes.com && filetype:docx && instreamset:(url title):docx
+func Query(domain, filetype string) string {
+	return fmt.Sprintf(
+		"site:%s && filetype:%s && instreamset:(url title):%s",
+		domain,
+		filetype,
+		filetype)
+}
+
+// dedupe drops repeated URLs while keeping the order of first appearance.
+func dedupe(urls []string) []string {
+	seen := make(map[string]bool, len(urls))
+	ret := urls[:0]
+	for _, u := range urls {
+		if seen[u] {
+			continue
+		}
+		seen[u] = true
+		ret = append(ret, u)
+	}
+	return ret
+}
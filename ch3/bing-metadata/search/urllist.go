@@ -0,0 +1,44 @@
+package search
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// URLList reads document URLs from a file, one per line, for offline runs or URLs collected elsewhere. Blank lines
+// and lines starting with # are skipped.
+type URLList struct {
+	Path string
+}
+
+func NewURLList(path string) *URLList {
+	return &URLList{Path: path}
+}
+
+// Search returns the listed URLs whose path ends in filetype, domain is ignored.
+func (l *URLList) Search(domain, filetype string) ([]string, error) {
+	f, err := os.Open(l.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	suffix := "." + strings.ToLower(strings.TrimPrefix(filetype, "."))
+	var urls []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if filetype != "" && !strings.HasSuffix(strings.ToLower(strings.SplitN(line, "?", 2)[0]), suffix) {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return dedupe(urls), nil
+}
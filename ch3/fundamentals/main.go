@@ -2,7 +2,9 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
+	"github.com/bilalcaliskan/blackhat-go/internal/httpclient"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -32,6 +34,32 @@ func main() {
 			case, you must close the response body after you’re done reading data from it.
 	*/
 
+	/*
+		The convenience functions above all use http.DefaultClient. Every request below goes through a client built by
+		the shared internal/httpclient package instead, so it can be sent through Burp (-proxy http://127.0.0.1:8080),
+		with TLS verification disabled (-insecure), a rotating User-Agent, retries and a request log (-http-log -).
+		The *http.Client it returns has the same Get(), Head(), Post() and Do() methods as the package functions.
+		With -har every request is recorded to an HTTP Archive, which ../har-replay sends again and compares with the
+		recorded responses.
+	*/
+	httpFlags := (&httpclient.Config{}).RegisterFlags(flag.CommandLine)
+	flag.Parse()
+	client, err := httpFlags.Client()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// Close writes the HAR file and request log, so it runs before log.Fatalln ends the program.
+	err = run(client)
+	if cerr := httpclient.Close(client); cerr != nil {
+		log.Println(cerr)
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// run sends the requests of this chapter through client.
+func run(client *http.Client) error {
 	// Read and display response body
 	/*
 		Inspecting various components of the HTTP response is a crucial aspect of any HTTP-related task, like reading
@@ -41,16 +69,16 @@ func main() {
 		checking, and prints the HTTP status code and response body to stdout.
 
 	*/
-	resp, err := http.Get("https://www.google.com/robots.txt")
+	resp, err := client.Get("https://www.google.com/robots.txt")
 	if err != nil {
-		log.Panicln(err)
+		return err
 	}
 	// Print HTTP Status
 	fmt.Println(resp.Status)
 	// Read and display response body
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		log.Panicln(err)
+		return err
 	}
 	fmt.Println(string(body))
 	defer resp.Body.Close()
@@ -71,36 +99,36 @@ func main() {
 		response data and then decoding the data into that struct. The details and actual implementation of parsing
 		other formats will be left up to you to determine.
 	*/
-	res, err := http.Post(
+	res, err := client.Post(
 		"http://IP:PORT/ping",
 		"application/json",
 		nil,
 	)
 	if err != nil {
-		log.Fatalln(err)
+		return err
 	}
 	var status Status
 	if err := json.NewDecoder(res.Body).Decode(&status); err != nil {
-		log.Fatalln(err)
+		return err
 	}
 	defer res.Body.Close()
 	log.Printf("%s -> %s\n", status.Status, status.Message)
 
-	resp, err = http.Head("https://www.google.com/robots.txt")
+	resp, err = client.Head("https://www.google.com/robots.txt")
 	if err != nil {
-		log.Panicln(err)
+		return err
 	}
 	fmt.Println(resp.Status)
 	body, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
-		log.Panicln(err.Error())
+		return err
 	}
 	fmt.Println(string(body))
 	resp.Body.Close()
 
 	form := url.Values{}
 	form.Add("foo", "bar")
-	resp, err = http.Post("https://www.google.com/robots.txt", "application/x-www-form-urlencoded",
+	resp, err = client.Post("https://www.google.com/robots.txt", "application/x-www-form-urlencoded",
 		strings.NewReader(form.Encode()))
 	/*
 		Go has an additional POST request convenience function, called PostForm(), which removes the tediousness of
@@ -117,7 +145,7 @@ func main() {
 		often toyed with the idea of creating a new web framework that exclusively uses DELETE for everything.
 	*/
 	if err != nil {
-		log.Panicln(err)
+		return err
 	}
 	defer resp.Body.Close()
 	fmt.Println(resp.Status)
@@ -133,10 +161,12 @@ func main() {
 	*/
 	req, err := http.NewRequest("DELETE", "https://www.google.com/robots.txt", nil)
 	if err != nil {
-		log.Panicln(err)
+		return err
 	}
-	var client http.Client
 	resp, err = client.Do(req)
+	if err != nil {
+		return err
+	}
 	defer resp.Body.Close()
 	fmt.Println(resp.Status)
 	/*
@@ -146,10 +176,14 @@ func main() {
 	*/
 
 	req, err = http.NewRequest("PUT", "https://www.google.com/robots.txt", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
 	resp, err = client.Do(req)
 	if err != nil {
-		log.Panicln(err)
+		return err
 	}
 	defer resp.Body.Close()
 	fmt.Println(resp.Status)
+	return nil
 }
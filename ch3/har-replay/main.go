@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/bilalcaliskan/blackhat-go/internal/httpclient"
+	"log"
+	"net/http"
+	"os"
+)
+
+// har-replay sends the requests recorded in a HAR file again, e.g. one written with -har, and reports the ones whose
+// status code or body changed since they were recorded.
+func main() {
+	var config httpclient.Config
+	httpFlags := config.RegisterFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] file.har\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if config.HARFile == flag.Arg(0) {
+		log.Fatalln("-har must not name the file being replayed")
+	}
+
+	h, err := httpclient.ReadHARFile(flag.Arg(0))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	client, err := httpFlags.Client()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	replay(client, h)
+	if err := httpclient.Close(client); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// replay prints how the response to each recorded request compares with the recorded one.
+func replay(client *http.Client, h *httpclient.HAR) {
+	for _, r := range httpclient.Replay(client, h) {
+		switch {
+		case r.Err != nil:
+			fmt.Printf("[!] %d %s %s: %v\n", r.Index, r.Method, r.URL, r.Err)
+		case r.Changed():
+			fmt.Printf("[*] %d %s %s: status %d, recorded %d, body changed: %t\n", r.Index, r.Method, r.URL, r.Got, r.Want, r.BodyChanged)
+		default:
+			fmt.Printf("[+] %d %s %s: unchanged\n", r.Index, r.Method, r.URL)
+		}
+	}
+}
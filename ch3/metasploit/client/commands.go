@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bilalcaliskan/blackhat-go/ch3/metasploit/rpc"
+	"github.com/bilalcaliskan/blackhat-go/internal/output"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func runSessions(args []string) error {
+	fs, opts := newFlagSet("sessions")
+	fs.Parse(args)
+	msf, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer msf.Logout()
+
+	sessions, err := msf.SessionList()
+	if err != nil {
+		return err
+	}
+
+	list := make([]rpc.SessionListRes, 0, len(sessions))
+	for _, session := range sessions {
+		list = append(list, session)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	var rows [][]string
+	for _, s := range list {
+		rows = append(rows, []string{
+			strconv.FormatUint(uint64(s.ID), 10),
+			s.Type,
+			fmt.Sprintf("%s:%d", s.SessionHost, s.SessionPort),
+			s.TunnelPeer,
+			s.ViaExploit,
+			s.Info,
+		})
+	}
+	return output.New(opts.format).Write(list, []string{"ID", "TYPE", "TARGET", "PEER", "EXPLOIT", "INFO"}, rows)
+}
+
+func runJobs(args []string) error {
+	fs, opts := newFlagSet("jobs")
+	fs.Parse(args)
+	msf, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer msf.Logout()
+
+	jobs, err := msf.JobList()
+	if err != nil {
+		return err
+	}
+
+	list := make([]rpc.Job, 0, len(jobs))
+	for id, name := range jobs {
+		list = append(list, rpc.Job{ID: id, Name: name})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	var rows [][]string
+	for _, j := range list {
+		rows = append(rows, []string{strconv.FormatUint(uint64(j.ID), 10), j.Name})
+	}
+	return output.New(opts.format).Write(list, []string{"ID", "NAME"}, rows)
+}
+
+func runModules(args []string) error {
+	if len(args) == 0 || args[0] != "search" {
+		return fmt.Errorf("usage: client modules search [flags] term")
+	}
+	fs, opts := newFlagSet("modules search")
+	fs.Parse(args[1:])
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: client modules search [flags] term")
+	}
+	term := strings.ToLower(fs.Arg(0))
+
+	msf, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer msf.Logout()
+
+	exploits, err := msf.ModuleExploits()
+	if err != nil {
+		return err
+	}
+	auxiliary, err := msf.ModuleAuxiliary()
+	if err != nil {
+		return err
+	}
+
+	var found []string
+	for _, list := range []struct {
+		moduleType string
+		names      []string
+	}{{rpc.ModuleExploit, exploits}, {rpc.ModuleAuxiliary, auxiliary}} {
+		for _, name := range list.names {
+			if strings.Contains(strings.ToLower(name), term) {
+				found = append(found, list.moduleType+"/"+name)
+			}
+		}
+	}
+	sort.Strings(found)
+
+	var rows [][]string
+	for _, name := range found {
+		rows = append(rows, []string{name})
+	}
+	return output.New(opts.format).Write(found, []string{"MODULE"}, rows)
+}
+
+func runModule(args []string) error {
+	fs, opts := newFlagSet("run")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: client run [flags] type/module [OPTION=value...]")
+	}
+	parts := strings.SplitN(fs.Arg(0), "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("module %q must be prefixed with its type, e.g. auxiliary/scanner/smb/smb_version", fs.Arg(0))
+	}
+
+	options := make(map[string]interface{})
+	for _, arg := range fs.Args()[1:] {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid option %q, expected OPTION=value", arg)
+		}
+		options[kv[0]] = kv[1]
+	}
+
+	msf, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer msf.Logout()
+
+	res, err := msf.ModuleExecute(parts[0], parts[1], options)
+	if err != nil {
+		return err
+	}
+	rows := [][]string{{strconv.FormatUint(uint64(res.JobID), 10), res.UUID}}
+	return output.New(opts.format).Write(res, []string{"JOB", "UUID"}, rows)
+}
+
+func runSession(args []string) error {
+	if len(args) == 0 || args[0] != "shell" {
+		return fmt.Errorf("usage: client session shell [flags] id")
+	}
+	fs, opts := newFlagSet("session shell")
+	fs.Parse(args[1:])
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: client session shell [flags] id")
+	}
+	id, err := strconv.ParseUint(fs.Arg(0), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid session id %q", fs.Arg(0))
+	}
+
+	msf, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer msf.Logout()
+	return interact(msf, uint32(id))
+}
+
+// interact connects stdin and stdout to the session until stdin is closed.
+func interact(msf *rpc.Metasploit, id uint32) error {
+	session, err := msf.Session(id)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Interacting with %s session %d, press Ctrl-D to detach\n", session.Type, session.ID)
+
+	go io.Copy(os.Stdout, session)
+	_, err = io.Copy(session, os.Stdin)
+	return err
+}
+
+func runWatch(args []string) error {
+	fs, opts := newFlagSet("watch")
+	interval := fs.Duration("interval", 5*time.Second, "how often sessions and jobs are polled")
+	webhook := fs.String("webhook", "", "also post every event as JSON to this URL")
+	fs.Parse(args)
+	if *interval <= 0 {
+		return fmt.Errorf("-interval must be positive")
+	}
+
+	msf, err := opts.client()
+	if err != nil {
+		return err
+	}
+	defer msf.Logout()
+
+	sinks := []rpc.Sink{rpc.NewJSONLinesSink(os.Stdout)}
+	if *webhook != "" {
+		sinks = append(sinks, rpc.NewWebhookSink(*webhook))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	w := rpc.NewWatcher(msf, *interval)
+	w.OnError = func(err error) { log.Printf("polling failed, retrying: %v", err) }
+	rpc.Forward(w.Watch(ctx), func(err error) { log.Println(err) }, sinks...)
+	if err := w.Err(); !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
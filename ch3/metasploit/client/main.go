@@ -1,31 +1,97 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/bilalcaliskan/blackhat-go/ch3/metasploit/rpc"
 	"log"
+	"os"
+	"time"
 )
 
-func main() {
-	host := "127.0.0.1:55552"
-	pass := "UmHioSkY"
-	user := "msf"
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"sessions", "sessions [flags]", runSessions},
+	{"jobs", "jobs [flags]", runJobs},
+	{"modules", "modules search [flags] term", runModules},
+	{"run", "run [flags] type/module [OPTION=value...]", runModule},
+	{"session", "session shell [flags] id", runSession},
+	{"watch", "watch [flags]", runWatch},
+}
 
-	if host == "" || pass == "" {
-		log.Fatalln("Missing required environment variable MSFHOST or MSFPASS")
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: client <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", c.usage)
 	}
-	msf, err := rpc.New(host, user, pass)
-	if err != nil {
-		log.Panicln(err)
+	fmt.Fprintln(os.Stderr, "\nThe RPC server is configured through MSFHOST, MSFUSER (default msf), MSFPASS or MSFTOKEN,")
+	fmt.Fprintln(os.Stderr, "and MSFSSL=1 for msfrpcd's SSL endpoint.")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
 	}
-	defer msf.Logout()
 
-	sessions, err := msf.SessionList()
-	if err != nil {
-		log.Panicln(err)
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				log.Fatalln(err)
+			}
+			return
+		}
 	}
-	fmt.Println("Sessions:")
-	for _, session := range sessions {
-		fmt.Printf("%5d  %s\n", session.ID, session.Info)
+	usage()
+	os.Exit(2)
+}
+
+// options holds the flags shared by every command.
+type options struct {
+	format   string
+	pin      string
+	insecure bool
+	timeout  time.Duration
+}
+
+// newFlagSet returns a flag set for the named command with the shared flags already defined.
+func newFlagSet(name string) (*flag.FlagSet, *options) {
+	opts := &options{}
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&opts.format, "format", "table", "output format: table, json or csv")
+	fs.StringVar(&opts.pin, "pin", "", "SHA-256 fingerprint of the server certificate when MSFSSL is set")
+	fs.BoolVar(&opts.insecure, "insecure", false, "skip verification of the server certificate, needed for msfrpcd's self-signed one unless -pin is set")
+	fs.DurationVar(&opts.timeout, "timeout", 30*time.Second, "timeout for each RPC call")
+	return fs, opts
+}
+
+// client connects to the RPC server configured through the environment.
+func (o *options) client() (*rpc.Metasploit, error) {
+	host := os.Getenv("MSFHOST")
+	user := os.Getenv("MSFUSER")
+	pass := os.Getenv("MSFPASS")
+	token := os.Getenv("MSFTOKEN")
+	if user == "" {
+		user = "msf"
+	}
+
+	if host == "" || (pass == "" && token == "") {
+		return nil, fmt.Errorf("missing required environment variable MSFHOST, or MSFPASS or MSFTOKEN")
 	}
+	return rpc.NewWithConfig(rpc.Config{
+		Host:               host,
+		User:               user,
+		Pass:               pass,
+		Token:              token,
+		SSL:                os.Getenv("MSFSSL") == "1" || os.Getenv("MSFSSL") == "true",
+		InsecureSkipVerify: o.insecure,
+		PinnedSHA256:       o.pin,
+		Timeout:            o.timeout,
+	})
 }
@@ -0,0 +1,116 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultURI is the path msfrpcd and the msgrpc plugin serve the API on.
+const DefaultURI = "/api"
+
+type Config struct {
+	// Host is the host:port the RPC server listens on, e.g. 127.0.0.1:55553.
+	Host string
+	URI  string
+	User string
+	Pass string
+	// Token is an existing, usually permanent, token. Login is skipped when it is set; User and Pass are then only
+	// used to log in again if the token expires.
+	Token string
+
+	SSL bool
+	// InsecureSkipVerify accepts any certificate, which is needed for the self-signed one msfrpcd generates
+	// unless PinnedSHA256 is set.
+	InsecureSkipVerify bool
+	// PinnedSHA256 is the hex encoded SHA-256 fingerprint of the server certificate, colons are ignored.
+	PinnedSHA256 string
+
+	// Timeout limits each request, zero means no limit.
+	Timeout time.Duration
+}
+
+// NewWithConfig creates a client from cfg and logs in, unless cfg.Token is set.
+func NewWithConfig(cfg Config) (*Metasploit, error) {
+	if cfg.Token == "" && (cfg.User == "" || cfg.Pass == "") {
+		return nil, errors.New("msf rpc: either a token or user and password are required")
+	}
+	uri := cfg.URI
+	if uri == "" {
+		uri = DefaultURI
+	}
+	if !strings.HasPrefix(uri, "/") {
+		uri = "/" + uri
+	}
+	scheme := "http"
+	if cfg.SSL {
+		scheme = "https"
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.SSL {
+		tlsConfig, err := newTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	msf := &Metasploit{
+		url:    fmt.Sprintf("%s://%s%s", scheme, cfg.Host, uri),
+		user:   cfg.User,
+		pass:   cfg.Pass,
+		client: &http.Client{Transport: transport, Timeout: cfg.Timeout},
+		ctx:    context.Background(),
+		auth:   &auth{token: cfg.Token, permanent: cfg.Token != ""},
+	}
+
+	if cfg.Token != "" {
+		return msf, nil
+	}
+	if err := msf.Login(); err != nil {
+		return nil, err
+	}
+	return msf, nil
+}
+
+func newTLSConfig(cfg Config) (*tls.Config, error) {
+	if cfg.PinnedSHA256 == "" {
+		return &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}, nil
+	}
+
+	pin, err := hex.DecodeString(strings.ReplaceAll(cfg.PinnedSHA256, ":", ""))
+	if err != nil || len(pin) != sha256.Size {
+		return nil, fmt.Errorf("msf rpc: invalid certificate fingerprint %q", cfg.PinnedSHA256)
+	}
+
+	// The pin replaces chain verification, so it also works for msfrpcd's self-signed certificate.
+	return &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("msf rpc: server sent no certificate")
+			}
+			sum := sha256.Sum256(rawCerts[0])
+			if !bytes.Equal(sum[:], pin) {
+				return fmt.Errorf("msf rpc: certificate fingerprint %x does not match the pinned one", sum)
+			}
+			return nil
+		},
+	}, nil
+}
+
+// WithContext returns a copy of the client whose requests use ctx. The copy shares the token with msf.
+func (msf *Metasploit) WithContext(ctx context.Context) *Metasploit {
+	c := *msf
+	c.ctx = ctx
+	return &c
+}
@@ -0,0 +1,227 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// DefaultConsoleTimeout is how long Run waits for a command to finish unless Console.Timeout says otherwise.
+const DefaultConsoleTimeout = 5 * time.Minute
+
+// consoleDestroyTimeout bounds the console.destroy call RunCommands makes on its way out.
+const consoleDestroyTimeout = 10 * time.Second
+
+type consoleCreateReq struct {
+	_msgpack struct{} `msgpack:",asArray"`
+	Method   string
+	Token    string
+	Options  map[string]interface{}
+}
+
+type consoleReq struct {
+	_msgpack  struct{} `msgpack:",asArray"`
+	Method    string
+	Token     string
+	ConsoleID string
+}
+
+type consoleWriteReq struct {
+	_msgpack  struct{} `msgpack:",asArray"`
+	Method    string
+	Token     string
+	ConsoleID string
+	Data      string
+}
+
+type consoleCreateRes struct {
+	ID     string `msgpack:"id"`
+	Prompt string `msgpack:"prompt"`
+	Busy   bool   `msgpack:"busy"`
+}
+
+type ConsoleReadRes struct {
+	Data   string `msgpack:"data"`
+	Prompt string `msgpack:"prompt"`
+	Busy   bool   `msgpack:"busy"`
+}
+
+type consoleWriteRes struct {
+	Wrote uint32 `msgpack:"wrote"`
+}
+
+// Console is an msfconsole instance running inside msfrpcd.
+type Console struct {
+	ID     string
+	Prompt string
+	// Timeout limits how long Run waits for the console to become idle, zero or less means no limit besides the
+	// context of the client.
+	Timeout time.Duration
+
+	msf *Metasploit
+}
+
+func (msf *Metasploit) ConsoleCreate() (*Console, error) {
+	newReq := func(token string) interface{} {
+		return &consoleCreateReq{Method: "console.create", Token: token, Options: map[string]interface{}{}}
+	}
+	var res consoleCreateRes
+	if err := msf.send(newReq, &res); err != nil {
+		return nil, err
+	}
+	return &Console{ID: res.ID, Prompt: res.Prompt, Timeout: DefaultConsoleTimeout, msf: msf}, nil
+}
+
+// Read returns the output the console produced since the previous read.
+func (c *Console) Read() (*ConsoleReadRes, error) {
+	newReq := func(token string) interface{} {
+		return &consoleReq{Method: "console.read", Token: token, ConsoleID: c.ID}
+	}
+	var res ConsoleReadRes
+	if err := c.msf.send(newReq, &res); err != nil {
+		return nil, err
+	}
+	if res.Prompt != "" {
+		c.Prompt = res.Prompt
+	}
+	return &res, nil
+}
+
+func (c *Console) Write(data string) error {
+	newReq := func(token string) interface{} {
+		return &consoleWriteReq{Method: "console.write", Token: token, ConsoleID: c.ID, Data: data}
+	}
+	var res consoleWriteRes
+	return c.msf.send(newReq, &res)
+}
+
+func (c *Console) Destroy() error {
+	newReq := func(token string) interface{} {
+		return &consoleReq{Method: "console.destroy", Token: token, ConsoleID: c.ID}
+	}
+	var res resultRes
+	return c.msf.send(newReq, &res)
+}
+
+// destroy destroys the console with a context of its own, so a console whose run was cancelled doesn't leak.
+func (c *Console) destroy() error {
+	ctx, cancel := context.WithTimeout(context.Background(), consoleDestroyTimeout)
+	defer cancel()
+	con := *c
+	con.msf = c.msf.WithContext(ctx)
+	return con.Destroy()
+}
+
+// wait reads until the console is idle and has no pending output, polling every PollInterval. It gives up when ctx
+// is done and returns the output read so far along with the error.
+func (c *Console) wait(ctx context.Context) (string, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	// Reads use ctx too, so a hanging call doesn't outlive the deadline.
+	con := *c
+	con.msf = c.msf.WithContext(ctx)
+
+	var out strings.Builder
+	timer := time.NewTimer(PollInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return out.String(), fmt.Errorf("msf rpc: console %s still busy: %w", c.ID, ctx.Err())
+		}
+		res, err := con.Read()
+		if ctx.Err() != nil {
+			return out.String(), fmt.Errorf("msf rpc: console %s still busy: %w", c.ID, ctx.Err())
+		}
+		if err != nil {
+			return out.String(), err
+		}
+		if con.Prompt != "" {
+			c.Prompt = con.Prompt
+		}
+		out.WriteString(res.Data)
+		if !res.Busy && res.Data == "" {
+			return out.String(), nil
+		}
+		timer.Reset(PollInterval)
+	}
+}
+
+// Run executes a single command and returns its output once the console is no longer busy. It fails when the
+// console is still busy after Timeout, returning the output read so far.
+func (c *Console) Run(command string) (string, error) {
+	return c.RunContext(c.msf.ctx, command)
+}
+
+// RunContext is like Run but also gives up when ctx is done.
+func (c *Console) RunContext(ctx context.Context, command string) (string, error) {
+	if err := c.Write(strings.TrimRight(command, "\n") + "\n"); err != nil {
+		return "", err
+	}
+	return c.wait(ctx)
+}
+
+// RunCommands runs each command in a fresh console, waiting for every command to finish before sending the next
+// one, and writes the prompt, command and output of each to w. The console is destroyed afterwards, even when the
+// context of the client is done. A command still running after DefaultConsoleTimeout fails the run, use WithContext
+// for a shorter deadline.
+func (msf *Metasploit) RunCommands(commands []string, w io.Writer) (err error) {
+	c, err := msf.ConsoleCreate()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if derr := c.destroy(); derr != nil {
+			if err == nil {
+				err = derr
+			} else {
+				err = fmt.Errorf("%w (destroying console %s: %v)", err, c.ID, derr)
+			}
+		}
+	}()
+
+	// Drain the banner printed when the console starts.
+	if _, err := c.wait(msf.ctx); err != nil {
+		return err
+	}
+
+	for _, command := range commands {
+		if _, err := io.WriteString(w, c.Prompt+command+"\n"); err != nil {
+			return err
+		}
+		out, err := c.Run(command)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseResource reads the commands of an msfconsole resource script, skipping blank lines and comments.
+// Embedded <ruby> blocks are rejected since they are evaluated by msfconsole itself.
+func ParseResource(r io.Reader) ([]string, error) {
+	var commands []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "<ruby>") {
+			return nil, errors.New("resource scripts with <ruby> blocks are not supported")
+		}
+		commands = append(commands, line)
+	}
+	return commands, scanner.Err()
+}
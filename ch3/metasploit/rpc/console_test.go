@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func fastPolling(t *testing.T) {
+	old := PollInterval
+	PollInterval = time.Millisecond
+	t.Cleanup(func() { PollInterval = old })
+}
+
+// consoleServer answers console calls, reporting the console busy for the first busyReads reads after a write.
+func consoleServer(t *testing.T, busyReads int32) *fakeRPC {
+	var reads int32
+	return newFakeRPC(t, func(args []interface{}) (int, interface{}) {
+		switch args[0] {
+		case "console.create":
+			return 0, map[string]interface{}{"id": "1", "prompt": "msf6 > "}
+		case "console.write":
+			atomic.StoreInt32(&reads, 0)
+			return 0, map[string]interface{}{"wrote": len(args[3].(string))}
+		case "console.read":
+			n := atomic.AddInt32(&reads, 1)
+			switch {
+			case busyReads < 0 || n <= busyReads:
+				return 0, map[string]interface{}{"data": "", "prompt": "msf6 > ", "busy": true}
+			case n == busyReads+1:
+				return 0, map[string]interface{}{"data": "done\n", "prompt": "msf6 > ", "busy": false}
+			}
+			return 0, map[string]interface{}{"data": "", "prompt": "msf6 > ", "busy": false}
+		case "console.destroy":
+			return 0, map[string]string{"result": "success"}
+		}
+		return http.StatusInternalServerError, rpcError("Unknown API Call")
+	})
+}
+
+func TestConsoleRun(t *testing.T) {
+	fastPolling(t)
+	c, err := consoleServer(t, 3).client(t, "permanent").ConsoleCreate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.Run("version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "done\n" {
+		t.Errorf("Run() = %q, want the command output", out)
+	}
+}
+
+func TestConsoleRunTimeout(t *testing.T) {
+	fastPolling(t)
+	c, err := consoleServer(t, -1).client(t, "permanent").ConsoleCreate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Timeout = 20 * time.Millisecond
+	start := time.Now()
+	if _, err := c.Run("exploit"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("gave up after %s", d)
+	}
+}
+
+func TestRunCommandsContext(t *testing.T) {
+	fastPolling(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	f := consoleServer(t, -1)
+	msf := f.client(t, "permanent").WithContext(ctx)
+	var out bytes.Buffer
+	if err := msf.RunCommands([]string{"use exploit/multi/handler", "run"}, &out); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	methods := f.methods()
+	if len(methods) == 0 || methods[len(methods)-1] != "console.destroy" {
+		t.Errorf("last call was not console.destroy: %v", methods)
+	}
+}
@@ -0,0 +1,157 @@
+package rpc
+
+// DBOptions filters the db.* calls. Every call accepts "workspace", most also accept "limit", "offset" and
+// "addresses", e.g. DBOptions{"workspace": "client-a", "limit": 100}.
+type DBOptions map[string]interface{}
+
+type dbReq struct {
+	_msgpack struct{} `msgpack:",asArray"`
+	Method   string
+	Token    string
+	Options  DBOptions
+}
+
+type dbWorkspacesReq struct {
+	_msgpack struct{} `msgpack:",asArray"`
+	Method   string
+	Token    string
+}
+
+type DBHost struct {
+	Address   string `msgpack:"address"`
+	MAC       string `msgpack:"mac"`
+	Name      string `msgpack:"name"`
+	State     string `msgpack:"state"`
+	OSName    string `msgpack:"os_name"`
+	OSFlavor  string `msgpack:"os_flavor"`
+	OSSP      string `msgpack:"os_sp"`
+	OSLang    string `msgpack:"os_lang"`
+	Purpose   string `msgpack:"purpose"`
+	Info      string `msgpack:"info"`
+	CreatedAt int64  `msgpack:"created_at"`
+	UpdatedAt int64  `msgpack:"updated_at"`
+}
+
+type DBService struct {
+	Host      string `msgpack:"host"`
+	Port      int    `msgpack:"port"`
+	Proto     string `msgpack:"proto"`
+	State     string `msgpack:"state"`
+	Name      string `msgpack:"name"`
+	Info      string `msgpack:"info"`
+	CreatedAt int64  `msgpack:"created_at"`
+	UpdatedAt int64  `msgpack:"updated_at"`
+}
+
+type DBVuln struct {
+	Host  string `msgpack:"host"`
+	Port  int    `msgpack:"port"`
+	Proto string `msgpack:"proto"`
+	Name  string `msgpack:"name"`
+	Refs  string `msgpack:"refs"`
+	Time  int64  `msgpack:"time"`
+}
+
+type DBCred struct {
+	Host        string `msgpack:"host"`
+	Port        int    `msgpack:"port"`
+	Proto       string `msgpack:"proto"`
+	ServiceName string `msgpack:"sname"`
+	Type        string `msgpack:"type"`
+	User        string `msgpack:"user"`
+	Pass        string `msgpack:"pass"`
+	Time        int64  `msgpack:"time"`
+	UpdatedAt   int64  `msgpack:"updated_at"`
+}
+
+type DBLoot struct {
+	Host        string      `msgpack:"host"`
+	Service     string      `msgpack:"service"`
+	LootType    string      `msgpack:"ltype"`
+	ContentType string      `msgpack:"ctype"`
+	Name        string      `msgpack:"name"`
+	Info        string      `msgpack:"info"`
+	Data        interface{} `msgpack:"data"`
+	CreatedAt   int64       `msgpack:"created_at"`
+	UpdatedAt   int64       `msgpack:"updated_at"`
+}
+
+type DBWorkspace struct {
+	ID        uint32 `msgpack:"id"`
+	Name      string `msgpack:"name"`
+	CreatedAt int64  `msgpack:"created_at"`
+	UpdatedAt int64  `msgpack:"updated_at"`
+}
+
+func (msf *Metasploit) dbSend(method string, opts DBOptions, res interface{}) error {
+	if opts == nil {
+		opts = DBOptions{}
+	}
+	newReq := func(token string) interface{} {
+		return &dbReq{Method: method, Token: token, Options: opts}
+	}
+	return msf.send(newReq, res)
+}
+
+func (msf *Metasploit) DBHosts(opts DBOptions) ([]DBHost, error) {
+	var res struct {
+		Hosts []DBHost `msgpack:"hosts"`
+	}
+	if err := msf.dbSend("db.hosts", opts, &res); err != nil {
+		return nil, err
+	}
+	return res.Hosts, nil
+}
+
+func (msf *Metasploit) DBServices(opts DBOptions) ([]DBService, error) {
+	var res struct {
+		Services []DBService `msgpack:"services"`
+	}
+	if err := msf.dbSend("db.services", opts, &res); err != nil {
+		return nil, err
+	}
+	return res.Services, nil
+}
+
+func (msf *Metasploit) DBVulns(opts DBOptions) ([]DBVuln, error) {
+	var res struct {
+		Vulns []DBVuln `msgpack:"vulns"`
+	}
+	if err := msf.dbSend("db.vulns", opts, &res); err != nil {
+		return nil, err
+	}
+	return res.Vulns, nil
+}
+
+func (msf *Metasploit) DBCreds(opts DBOptions) ([]DBCred, error) {
+	var res struct {
+		Creds []DBCred `msgpack:"creds"`
+	}
+	if err := msf.dbSend("db.creds", opts, &res); err != nil {
+		return nil, err
+	}
+	return res.Creds, nil
+}
+
+func (msf *Metasploit) DBLoot(opts DBOptions) ([]DBLoot, error) {
+	var res struct {
+		Loots []DBLoot `msgpack:"loots"`
+	}
+	if err := msf.dbSend("db.loot", opts, &res); err != nil {
+		return nil, err
+	}
+	return res.Loots, nil
+}
+
+func (msf *Metasploit) DBWorkspaces() ([]DBWorkspace, error) {
+	newReq := func(token string) interface{} {
+		return &dbWorkspacesReq{Method: "db.workspaces", Token: token}
+	}
+	var res struct {
+		Workspaces []DBWorkspace `msgpack:"workspaces"`
+	}
+	if err := msf.send(newReq, &res); err != nil {
+		return nil, err
+	}
+	return res.Workspaces, nil
+}
@@ -0,0 +1,124 @@
+package rpc
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSessionList(t *testing.T) {
+	f := newFakeRPC(t, func(args []interface{}) (int, interface{}) {
+		if args[0] != "session.list" {
+			return http.StatusInternalServerError, rpcError("Unknown API Call")
+		}
+		return 0, map[uint32]map[string]interface{}{
+			3: {
+				"type":         "meterpreter",
+				"tunnel_local": "10.0.0.5:4444",
+				"tunnel_peer":  "10.0.0.20:49733",
+				"via_exploit":  "exploit/windows/smb/ms17_010_eternalblue",
+				"via_payload":  "payload/windows/x64/meterpreter/reverse_tcp",
+				"desc":         "Meterpreter",
+				"info":         "NT AUTHORITY\\SYSTEM @ DC01",
+				"workspace":    "client-a",
+				"session_host": "10.0.0.20",
+				"session_port": 445,
+				"username":     "msf",
+				"uuid":         "kx1ldtoq",
+				"exploit_uuid": "6f2tghvo",
+			},
+		}
+	})
+	sessions, err := f.client(t, "permanent").SessionList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[uint32]SessionListRes{3: {
+		ID:          3,
+		Type:        "meterpreter",
+		TunnelLocal: "10.0.0.5:4444",
+		TunnelPeer:  "10.0.0.20:49733",
+		ViaExploit:  "exploit/windows/smb/ms17_010_eternalblue",
+		ViaPayload:  "payload/windows/x64/meterpreter/reverse_tcp",
+		Description: "Meterpreter",
+		Info:        "NT AUTHORITY\\SYSTEM @ DC01",
+		Workspace:   "client-a",
+		SessionHost: "10.0.0.20",
+		SessionPort: 445,
+		Username:    "msf",
+		UUID:        "kx1ldtoq",
+		ExploitUUID: "6f2tghvo",
+	}}
+	if !reflect.DeepEqual(sessions, want) {
+		t.Errorf("SessionList() =\n%+v\nwant\n%+v", sessions, want)
+	}
+}
+
+func TestDBWrappers(t *testing.T) {
+	var workspace interface{}
+	f := newFakeRPC(t, func(args []interface{}) (int, interface{}) {
+		if len(args) > 2 {
+			if opts, ok := args[2].(map[interface{}]interface{}); ok {
+				workspace = opts["workspace"]
+			}
+		}
+		switch args[0] {
+		case "db.hosts":
+			return 0, map[string]interface{}{"hosts": []map[string]interface{}{
+				{"address": "10.0.0.20", "mac": "00:0c:29:aa:bb:cc", "name": "DC01", "state": "alive", "os_name": "Windows Server 2016", "created_at": 1700000000},
+			}}
+		case "db.services":
+			return 0, map[string]interface{}{"services": []map[string]interface{}{
+				{"host": "10.0.0.20", "port": 445, "proto": "tcp", "state": "open", "name": "smb"},
+			}}
+		case "db.vulns":
+			return 0, map[string]interface{}{"vulns": []map[string]interface{}{
+				{"host": "10.0.0.20", "port": 445, "proto": "tcp", "name": "MS17-010", "refs": "CVE-2017-0144"},
+			}}
+		case "db.creds":
+			return 0, map[string]interface{}{"creds": []map[string]interface{}{
+				{"host": "10.0.0.20", "port": 445, "proto": "tcp", "sname": "smb", "type": "password", "user": "administrator", "pass": "Winter2024!"},
+			}}
+		case "db.loot":
+			return 0, map[string]interface{}{"loots": []map[string]interface{}{
+				{"host": "10.0.0.20", "service": "smb", "ltype": "windows.hashes", "ctype": "text/plain", "name": "hashes.txt"},
+			}}
+		case "db.workspaces":
+			return 0, map[string]interface{}{"workspaces": []map[string]interface{}{
+				{"id": 1, "name": "default"}, {"id": 2, "name": "client-a"},
+			}}
+		}
+		return http.StatusInternalServerError, rpcError("Unknown API Call")
+	})
+	msf := f.client(t, "permanent")
+	opts := DBOptions{"workspace": "client-a"}
+
+	hosts, err := msf.DBHosts(opts)
+	if err != nil || len(hosts) != 1 || hosts[0].Address != "10.0.0.20" || hosts[0].OSName != "Windows Server 2016" ||
+		hosts[0].CreatedAt != 1700000000 {
+		t.Errorf("DBHosts() = %+v, %v", hosts, err)
+	}
+	if workspace != "client-a" {
+		t.Errorf("workspace option sent as %v", workspace)
+	}
+	services, err := msf.DBServices(opts)
+	if err != nil || len(services) != 1 || services[0].Port != 445 || services[0].Name != "smb" {
+		t.Errorf("DBServices() = %+v, %v", services, err)
+	}
+	vulns, err := msf.DBVulns(opts)
+	if err != nil || len(vulns) != 1 || vulns[0].Name != "MS17-010" || vulns[0].Refs != "CVE-2017-0144" {
+		t.Errorf("DBVulns() = %+v, %v", vulns, err)
+	}
+	creds, err := msf.DBCreds(nil)
+	if err != nil || len(creds) != 1 || creds[0].ServiceName != "smb" || creds[0].User != "administrator" {
+		t.Errorf("DBCreds() = %+v, %v", creds, err)
+	}
+	loot, err := msf.DBLoot(opts)
+	if err != nil || len(loot) != 1 || loot[0].LootType != "windows.hashes" || loot[0].ContentType != "text/plain" {
+		t.Errorf("DBLoot() = %+v, %v", loot, err)
+	}
+	workspaces, err := msf.DBWorkspaces()
+	if err != nil || len(workspaces) != 2 || workspaces[1].ID != 2 || workspaces[1].Name != "client-a" {
+		t.Errorf("DBWorkspaces() = %+v, %v", workspaces, err)
+	}
+}
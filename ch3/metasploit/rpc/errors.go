@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Error is returned when msfrpcd answers a call with an error response, e.g. a failed login or an unknown method.
+type Error struct {
+	StatusCode int
+	Code       int
+	Class      string
+	Message    string
+	Backtrace  []string
+}
+
+type errorRes struct {
+	Error        bool     `msgpack:"error"`
+	ErrorCode    int      `msgpack:"error_code"`
+	ErrorClass   string   `msgpack:"error_class"`
+	ErrorString  string   `msgpack:"error_string"`
+	ErrorMessage string   `msgpack:"error_message"`
+	Backtrace    []string `msgpack:"error_backtrace"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.Class != "" {
+		return fmt.Sprintf("msf rpc: %s: %s", e.Class, msg)
+	}
+	return fmt.Sprintf("msf rpc: %s", msg)
+}
+
+// TokenExpired reports whether the call was rejected because the authentication token is no longer valid.
+func (e *Error) TokenExpired() bool {
+	return strings.Contains(e.Message, "Invalid Authentication Token")
+}
+
+// LoginFailed reports whether auth.login rejected the username or password.
+func (e *Error) LoginFailed() bool {
+	return strings.Contains(e.Message, "Login Failed")
+}
+
+// IsError unwraps err into an *Error if possible.
+func IsError(err error) (*Error, bool) {
+	var rpcErr *Error
+	ok := errors.As(err, &rpcErr)
+	return rpcErr, ok
+}
+
+func newError(status int, res *errorRes) *Error {
+	msg := res.ErrorMessage
+	if msg == "" {
+		msg = res.ErrorString
+	}
+	return &Error{
+		StatusCode: status,
+		Code:       res.ErrorCode,
+		Class:      res.ErrorClass,
+		Message:    msg,
+		Backtrace:  res.Backtrace,
+	}
+}
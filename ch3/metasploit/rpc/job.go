@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"strconv"
+)
+
+// Job is a running job as listed by JobList.
+type Job struct {
+	ID   uint32 `json:"id"`
+	Name string `json:"name"`
+}
+
+type jobListReq struct {
+	_msgpack struct{} `msgpack:",asArray"`
+	Method   string
+	Token    string
+}
+
+type jobReq struct {
+	_msgpack struct{} `msgpack:",asArray"`
+	Method   string
+	Token    string
+	JobID    string
+}
+
+type JobInfoRes struct {
+	ID        uint32                 `msgpack:"jid"`
+	Name      string                 `msgpack:"name"`
+	StartTime int64                  `msgpack:"start_time"`
+	URIPath   string                 `msgpack:"uripath"`
+	Datastore map[string]interface{} `msgpack:"datastore"`
+}
+
+type jobStopRes struct {
+	Result string `msgpack:"result"`
+}
+
+// JobList returns the names of the running jobs, keyed by job ID.
+func (msf *Metasploit) JobList() (map[uint32]string, error) {
+	newReq := func(token string) interface{} {
+		return &jobListReq{Method: "job.list", Token: token}
+	}
+	res := make(map[string]string)
+	if err := msf.send(newReq, &res); err != nil {
+		return nil, err
+	}
+
+	jobs := make(map[uint32]string, len(res))
+	for id, name := range res {
+		jid, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		jobs[uint32(jid)] = name
+	}
+	return jobs, nil
+}
+
+func (msf *Metasploit) JobInfo(id uint32) (*JobInfoRes, error) {
+	newReq := func(token string) interface{} {
+		return &jobReq{Method: "job.info", Token: token, JobID: strconv.FormatUint(uint64(id), 10)}
+	}
+	var res JobInfoRes
+	if err := msf.send(newReq, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (msf *Metasploit) JobStop(id uint32) error {
+	newReq := func(token string) interface{} {
+		return &jobReq{Method: "job.stop", Token: token, JobID: strconv.FormatUint(uint64(id), 10)}
+	}
+	var res jobStopRes
+	return msf.send(newReq, &res)
+}
@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestJobWrappers(t *testing.T) {
+	f := newFakeRPC(t, func(args []interface{}) (int, interface{}) {
+		switch args[0] {
+		case "job.list":
+			return 0, map[string]string{"0": "Exploit: multi/handler", "12": "Auxiliary: scanner/smb/smb_version"}
+		case "job.info":
+			if args[2] != "12" {
+				return http.StatusInternalServerError, rpcError("Invalid Job")
+			}
+			return 0, map[string]interface{}{
+				"jid":        12,
+				"name":       "Auxiliary: scanner/smb/smb_version",
+				"start_time": 1600000000,
+				"datastore":  map[string]interface{}{"RHOSTS": "10.0.1.0/24"},
+			}
+		case "job.stop":
+			return 0, map[string]string{"result": "success"}
+		}
+		return http.StatusInternalServerError, rpcError("Unknown API Call")
+	})
+	msf := f.client(t, "permanent")
+
+	jobs, err := msf.JobList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0] != "Exploit: multi/handler" || jobs[12] == "" {
+		t.Errorf("JobList() = %v", jobs)
+	}
+
+	info, err := msf.JobInfo(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ID != 12 || info.StartTime != 1600000000 || info.Datastore["RHOSTS"] != "10.0.1.0/24" {
+		t.Errorf("JobInfo() = %+v", info)
+	}
+	if _, err := msf.JobInfo(13); err == nil {
+		t.Error("JobInfo(13) succeeded for an unknown job")
+	}
+
+	if err := msf.JobStop(12); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestJobListInvalidID(t *testing.T) {
+	f := newFakeRPC(t, func(args []interface{}) (int, interface{}) {
+		return 0, map[string]string{"not-a-number": "Exploit: multi/handler"}
+	})
+	if _, err := f.client(t, "permanent").JobList(); err == nil {
+		t.Error("expected an error for a non-numeric job ID")
+	}
+}
@@ -0,0 +1,135 @@
+package rpc
+
+// Module types accepted by the module.* methods.
+const (
+	ModuleExploit   = "exploit"
+	ModuleAuxiliary = "auxiliary"
+	ModulePost      = "post"
+	ModulePayload   = "payload"
+)
+
+type moduleListReq struct {
+	_msgpack struct{} `msgpack:",asArray"`
+	Method   string
+	Token    string
+}
+
+type moduleListRes struct {
+	Modules []string `msgpack:"modules"`
+}
+
+type moduleReq struct {
+	_msgpack   struct{} `msgpack:",asArray"`
+	Method     string
+	Token      string
+	ModuleType string
+	ModuleName string
+}
+
+type ModuleInfoRes struct {
+	Type          string            `msgpack:"type"`
+	Name          string            `msgpack:"name"`
+	FullName      string            `msgpack:"fullname"`
+	Rank          int               `msgpack:"rank"`
+	Description   string            `msgpack:"description"`
+	License       string            `msgpack:"license"`
+	FilePath      string            `msgpack:"filepath"`
+	References    [][]string        `msgpack:"references"`
+	Authors       []string          `msgpack:"authors"`
+	Privileged    bool              `msgpack:"privileged"`
+	Check         bool              `msgpack:"check"`
+	Stance        string            `msgpack:"stance"`
+	Targets       map[uint32]string `msgpack:"targets"`
+	DefaultTarget uint32            `msgpack:"default_target"`
+	Actions       map[uint32]string `msgpack:"actions"`
+	DefaultAction string            `msgpack:"default_action"`
+}
+
+type ModuleOption struct {
+	Type     string      `msgpack:"type"`
+	Required bool        `msgpack:"required"`
+	Advanced bool        `msgpack:"advanced"`
+	Evasion  bool        `msgpack:"evasion"`
+	Desc     string      `msgpack:"desc"`
+	Default  interface{} `msgpack:"default"`
+	Enums    []string    `msgpack:"enums"`
+}
+
+type moduleExecuteReq struct {
+	_msgpack   struct{} `msgpack:",asArray"`
+	Method     string
+	Token      string
+	ModuleType string
+	ModuleName string
+	Options    map[string]interface{}
+}
+
+type ModuleExecuteRes struct {
+	JobID uint32 `msgpack:"job_id"`
+	UUID  string `msgpack:"uuid"`
+}
+
+func (msf *Metasploit) moduleList(method string) ([]string, error) {
+	newReq := func(token string) interface{} {
+		return &moduleListReq{Method: method, Token: token}
+	}
+	var res moduleListRes
+	if err := msf.send(newReq, &res); err != nil {
+		return nil, err
+	}
+	return res.Modules, nil
+}
+
+// ModuleExploits returns the names of all exploit modules, e.g. "windows/smb/ms17_010_eternalblue".
+func (msf *Metasploit) ModuleExploits() ([]string, error) {
+	return msf.moduleList("module.exploits")
+}
+
+// ModuleAuxiliary returns the names of all auxiliary modules, e.g. "scanner/smb/smb_version".
+func (msf *Metasploit) ModuleAuxiliary() ([]string, error) {
+	return msf.moduleList("module.auxiliary")
+}
+
+func (msf *Metasploit) ModuleInfo(moduleType, name string) (*ModuleInfoRes, error) {
+	newReq := func(token string) interface{} {
+		return &moduleReq{Method: "module.info", Token: token, ModuleType: moduleType, ModuleName: name}
+	}
+	var res ModuleInfoRes
+	if err := msf.send(newReq, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ModuleOptions returns the datastore options of a module, keyed by option name.
+func (msf *Metasploit) ModuleOptions(moduleType, name string) (map[string]ModuleOption, error) {
+	newReq := func(token string) interface{} {
+		return &moduleReq{Method: "module.options", Token: token, ModuleType: moduleType, ModuleName: name}
+	}
+	res := make(map[string]ModuleOption)
+	if err := msf.send(newReq, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ModuleExecute launches a module as a background job with the given datastore options, e.g. RHOSTS.
+func (msf *Metasploit) ModuleExecute(moduleType, name string, options map[string]interface{}) (*ModuleExecuteRes, error) {
+	if options == nil {
+		options = map[string]interface{}{}
+	}
+	newReq := func(token string) interface{} {
+		return &moduleExecuteReq{
+			Method:     "module.execute",
+			Token:      token,
+			ModuleType: moduleType,
+			ModuleName: name,
+			Options:    options,
+		}
+	}
+	var res ModuleExecuteRes
+	if err := msf.send(newReq, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
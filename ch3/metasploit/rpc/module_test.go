@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestModuleWrappers(t *testing.T) {
+	f := newFakeRPC(t, func(args []interface{}) (int, interface{}) {
+		switch args[0] {
+		case "module.exploits":
+			return 0, map[string]interface{}{"modules": []string{"windows/smb/ms17_010_eternalblue"}}
+		case "module.auxiliary":
+			return 0, map[string]interface{}{"modules": []string{"scanner/smb/smb_version", "scanner/http/title"}}
+		case "module.info":
+			return 0, map[string]interface{}{
+				"type":           "auxiliary",
+				"name":           "SMB Version Detection",
+				"fullname":       "auxiliary/scanner/smb/smb_version",
+				"rank":           300,
+				"references":     [][]string{{"URL", "https://example.com"}},
+				"authors":        []string{"hdm"},
+				"check":          false,
+				"actions":        map[uint32]string{0: "Scan"},
+				"default_action": "Scan",
+			}
+		case "module.options":
+			return 0, map[string]interface{}{
+				"RHOSTS":  map[string]interface{}{"type": "addressrange", "required": true, "desc": "The target host(s)"},
+				"THREADS": map[string]interface{}{"type": "integer", "required": true, "default": 1},
+			}
+		case "module.execute":
+			return 0, map[string]interface{}{"job_id": 4, "uuid": "abcd1234"}
+		}
+		return http.StatusInternalServerError, rpcError("Unknown API Call")
+	})
+	msf := f.client(t, "permanent")
+
+	exploits, err := msf.ModuleExploits()
+	if err != nil || !reflect.DeepEqual(exploits, []string{"windows/smb/ms17_010_eternalblue"}) {
+		t.Errorf("ModuleExploits() = %v, %v", exploits, err)
+	}
+	aux, err := msf.ModuleAuxiliary()
+	if err != nil || len(aux) != 2 {
+		t.Errorf("ModuleAuxiliary() = %v, %v", aux, err)
+	}
+
+	info, err := msf.ModuleInfo(ModuleAuxiliary, "scanner/smb/smb_version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.FullName != "auxiliary/scanner/smb/smb_version" || info.Rank != 300 || info.Actions[0] != "Scan" ||
+		len(info.References) != 1 || info.References[0][1] != "https://example.com" {
+		t.Errorf("ModuleInfo() = %+v", info)
+	}
+
+	opts, err := msf.ModuleOptions(ModuleAuxiliary, "scanner/smb/smb_version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := opts["RHOSTS"]; !o.Required || o.Type != "addressrange" {
+		t.Errorf("RHOSTS = %+v", o)
+	}
+	if o := opts["THREADS"]; o.Default == nil {
+		t.Errorf("THREADS default missing: %+v", o)
+	}
+
+	res, err := msf.ModuleExecute(ModuleAuxiliary, "scanner/smb/smb_version", map[string]interface{}{"RHOSTS": "10.0.1.0/24"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.JobID != 4 || res.UUID != "abcd1234" {
+		t.Errorf("ModuleExecute() = %+v", res)
+	}
+
+	// The positional arguments are sent in the order msfrpcd expects them.
+	calls := f.calls
+	last := calls[len(calls)-1]
+	if len(last) != 5 || last[1] != "permanent" || last[2] != ModuleAuxiliary || last[3] != "scanner/smb/smb_version" {
+		t.Fatalf("module.execute sent %v", last)
+	}
+	if options, ok := last[4].(map[interface{}]interface{}); !ok || options["RHOSTS"] != "10.0.1.0/24" {
+		t.Errorf("module.execute options = %#v", last[4])
+	}
+}
+
+func TestModuleExecuteError(t *testing.T) {
+	f := newFakeRPC(t, func(args []interface{}) (int, interface{}) {
+		return http.StatusInternalServerError, rpcError("Invalid Module")
+	})
+	_, err := f.client(t, "permanent").ModuleExecute(ModuleExploit, "does/not/exist", nil)
+	rpcErr, ok := IsError(err)
+	if !ok || rpcErr.Message != "Invalid Module" || rpcErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("got %v, want an *Error", err)
+	}
+}
@@ -2,30 +2,53 @@ package rpc
 
 import (
 	"bytes"
-	"fmt"
+	"context"
+	"errors"
 	"gopkg.in/vmihailenco/msgpack.v2"
+	"io/ioutil"
 	"net/http"
+	"sync"
 )
 
 type Metasploit struct {
-	host  string
-	user  string
-	pass  string
+	url    string
+	user   string
+	pass   string
+	client *http.Client
+	ctx    context.Context
+	auth   *auth
+}
+
+// auth holds the token shared by a client and the copies returned by WithContext.
+type auth struct {
+	mu    sync.Mutex
 	token string
+	// gen counts the logins, so concurrent calls that find the same token expired log in again only once.
+	gen uint64
+	// permanent is set for tokens passed in through Config, which must never be logged out.
+	permanent bool
+
+	// login serializes logging in again after a token expired.
+	login sync.Mutex
 }
 
+// New connects to the plain HTTP endpoint of the msgrpc plugin and logs in with user and pass. Use NewWithConfig
+// for msfrpcd's SSL endpoint or token authentication.
 func New(host, user, pass string) (*Metasploit, error) {
-	msf := &Metasploit{
-		host: host,
-		user: user,
-		pass: pass,
-	}
+	return NewWithConfig(Config{Host: host, User: user, Pass: pass})
+}
 
-	if err := msf.Login(); err != nil {
-		return nil, err
-	}
+// token returns the current authentication token.
+func (msf *Metasploit) token() string {
+	token, _ := msf.current()
+	return token
+}
 
-	return msf, nil
+// current returns the current authentication token and the login it came from.
+func (msf *Metasploit) current() (string, uint64) {
+	msf.auth.mu.Lock()
+	defer msf.auth.mu.Unlock()
+	return msf.auth.token, msf.auth.gen
 }
 
 type SessionListReq struct {
@@ -35,20 +58,20 @@ type SessionListReq struct {
 }
 
 type SessionListRes struct {
-	ID          uint32 `msgpack:",omitempty"`
-	Type        string `msgpack:"type"`
-	TunnelLocal string `msgpack:"tunnel_local"`
-	TunnelPeer  string `msgpack:"tunnel_peer"`
-	ViaExploit  string `msgpack:"via_exploit"`
-	ViaPayload  string `msgpack:"via_payload"`
-	Description string `msgpack:"desc"`
-	Info        string `msgpack:"info"`
-	Workspace   string `msgpack:"workspace"`
-	SessionHost string `msgpack"session_host"`
-	SessionPort int    `msgpack"session_port"`
-	Username    string `msgpack:"username"`
-	UUID        string `msgpack:"uuid"`
-	ExploitUUID string `msgpack:"exploit_uuid"`
+	ID          uint32 `msgpack:",omitempty" json:"id"`
+	Type        string `msgpack:"type" json:"type"`
+	TunnelLocal string `msgpack:"tunnel_local" json:"tunnel_local"`
+	TunnelPeer  string `msgpack:"tunnel_peer" json:"tunnel_peer"`
+	ViaExploit  string `msgpack:"via_exploit" json:"via_exploit"`
+	ViaPayload  string `msgpack:"via_payload" json:"via_payload"`
+	Description string `msgpack:"desc" json:"desc"`
+	Info        string `msgpack:"info" json:"info"`
+	Workspace   string `msgpack:"workspace" json:"workspace"`
+	SessionHost string `msgpack:"session_host" json:"session_host"`
+	SessionPort int    `msgpack:"session_port" json:"session_port"`
+	Username    string `msgpack:"username" json:"username"`
+	UUID        string `msgpack:"uuid" json:"uuid"`
+	ExploitUUID string `msgpack:"exploit_uuid" json:"exploit_uuid"`
 }
 
 type loginReq struct {
@@ -58,12 +81,10 @@ type loginReq struct {
 	Password string
 }
 
+// loginRes holds a successful login, failures are decoded by post into an *Error.
 type loginRes struct {
-	Result       string `msgpack:"result"`
-	Token        string `msgpack:"token"`
-	Error        bool   `msgpack:"error"`
-	ErrorClass   string `msgpack:"error_class"`
-	ErrorMessage string `msgpack:"error_message"`
+	Result string `msgpack:"result"`
+	Token  string `msgpack:"token"`
 }
 
 type logoutReq struct {
@@ -77,21 +98,64 @@ type logoutRes struct {
 	Result string `msgpack:"result"`
 }
 
-func (msf *Metasploit) send(req interface{}, res interface{}) error {
+// send builds a request for the current token with newReq, posts it to the API and decodes the answer into res,
+// which must be a pointer. Error responses are returned as *Error. If the token expired and credentials are known,
+// send logs in again and retries once with a request built for the new token.
+func (msf *Metasploit) send(newReq func(token string) interface{}, res interface{}) error {
+	token, gen := msf.current()
+	err := msf.post(newReq(token), res)
+	if rpcErr, ok := IsError(err); !ok || !rpcErr.TokenExpired() || msf.user == "" || msf.pass == "" {
+		return err
+	}
+
+	if err := msf.relogin(gen); err != nil {
+		return err
+	}
+	token, _ = msf.current()
+	return msf.post(newReq(token), res)
+}
+
+// relogin logs in again unless another call already did since the token of login gen was found to be expired.
+func (msf *Metasploit) relogin(gen uint64) error {
+	msf.auth.login.Lock()
+	defer msf.auth.login.Unlock()
+	if _, current := msf.current(); current != gen {
+		return nil
+	}
+	return msf.Login()
+}
+
+func (msf *Metasploit) post(req interface{}, res interface{}) error {
 	buf := new(bytes.Buffer)
-	msgpack.NewEncoder(buf).Encode(req)
-	dest := fmt.Sprintf("http://%s/api", msf.host)
-	r, err := http.Post(dest, "binary/message-pack", buf)
+	if err := msgpack.NewEncoder(buf).Encode(req); err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(msf.ctx, http.MethodPost, msf.url, buf)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "binary/message-pack")
+	r, err := msf.client.Do(httpReq)
 	if err != nil {
 		return err
 	}
 	defer r.Body.Close()
 
-	if err := msgpack.NewDecoder(r.Body).Decode(&res); err != nil {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	// Failed calls answer with an error map, usually along with a 401 or 500 status.
+	var errRes errorRes
+	if err := msgpack.Unmarshal(body, &errRes); err == nil && errRes.Error {
+		return newError(r.StatusCode, &errRes)
+	}
+	if r.StatusCode != http.StatusOK {
+		return &Error{StatusCode: r.StatusCode, Message: r.Status}
+	}
+
+	return msgpack.Unmarshal(body, res)
 }
 
 func (msf *Metasploit) Login() error {
@@ -101,31 +165,50 @@ func (msf *Metasploit) Login() error {
 		Password: msf.pass,
 	}
 	var res loginRes
-	if err := msf.send(ctx, &res); err != nil {
+	if err := msf.post(ctx, &res); err != nil {
 		return err
 	}
-	msf.token = res.Token
+	if res.Result != "success" || res.Token == "" {
+		return errors.New("msf rpc: login did not return a token")
+	}
+	msf.auth.mu.Lock()
+	msf.auth.token = res.Token
+	msf.auth.gen++
+	msf.auth.permanent = false
+	msf.auth.mu.Unlock()
 	return nil
 }
 
+// Logout invalidates the temporary token obtained by Login. Permanent tokens passed in through Config are kept.
 func (msf *Metasploit) Logout() error {
+	msf.auth.mu.Lock()
+	token, permanent := msf.auth.token, msf.auth.permanent
+	msf.auth.mu.Unlock()
+	if permanent || token == "" {
+		return nil
+	}
+
 	ctx := &logoutReq{
 		Method:      "auth.logout",
-		Token:       msf.token,
-		LogoutToken: msf.token,
+		Token:       token,
+		LogoutToken: token,
 	}
 	var res logoutRes
-	if err := msf.send(ctx, &res); err != nil {
+	if err := msf.post(ctx, &res); err != nil {
 		return err
 	}
-	msf.token = ""
+	msf.auth.mu.Lock()
+	msf.auth.token = ""
+	msf.auth.mu.Unlock()
 	return nil
 }
 
 func (msf *Metasploit) SessionList() (map[uint32]SessionListRes, error) {
-	req := &SessionListReq{Method: "session.list", Token: msf.token}
+	newReq := func(token string) interface{} {
+		return &SessionListReq{Method: "session.list", Token: token}
+	}
 	res := make(map[uint32]SessionListRes)
-	if err := msf.send(req, &res); err != nil {
+	if err := msf.send(newReq, &res); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,151 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLogin(t *testing.T) {
+	f := newFakeRPC(t, func(args []interface{}) (int, interface{}) {
+		switch args[0] {
+		case "auth.login":
+			if args[1] != "msf" || args[2] != "pass" {
+				return http.StatusUnauthorized, rpcError("Login Failed")
+			}
+			return 0, map[string]string{"result": "success", "token": "TEMP123"}
+		case "auth.logout":
+			return 0, map[string]string{"result": "success"}
+		}
+		return http.StatusInternalServerError, rpcError("Unknown API Call")
+	})
+	host := strings.TrimPrefix(f.URL, "http://")
+
+	msf, err := New(host, "msf", "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msf.token() != "TEMP123" {
+		t.Errorf("token = %q, want TEMP123", msf.token())
+	}
+	if err := msf.Logout(); err != nil {
+		t.Fatal(err)
+	}
+	if msf.token() != "" {
+		t.Errorf("token %q kept after Logout", msf.token())
+	}
+
+	_, err = New(host, "msf", "wrong")
+	rpcErr, ok := IsError(err)
+	if !ok || !rpcErr.LoginFailed() || rpcErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got %v, want a failed login", err)
+	}
+}
+
+func TestLoginWithoutToken(t *testing.T) {
+	f := newFakeRPC(t, func(args []interface{}) (int, interface{}) {
+		return 0, map[string]string{"result": "success"}
+	})
+	if _, err := New(strings.TrimPrefix(f.URL, "http://"), "msf", "pass"); err == nil {
+		t.Error("expected an error for a login without a token")
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	// The server no longer accepts the token the client starts with.
+	valid := "EXPIRED"
+	f := newFakeRPC(t, func(args []interface{}) (int, interface{}) {
+		switch args[0] {
+		case "auth.login":
+			valid = "NEW"
+			return 0, map[string]string{"result": "success", "token": "NEW"}
+		case "session.list":
+			if args[1] != valid {
+				return http.StatusUnauthorized, rpcError("Invalid Authentication Token")
+			}
+			return 0, map[uint32]interface{}{1: map[string]interface{}{"type": "shell"}}
+		}
+		return http.StatusInternalServerError, rpcError("Unknown API Call")
+	})
+
+	msf := f.client(t, "OLD")
+	sessions, err := msf.SessionList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessions[1].Type != "shell" {
+		t.Errorf("SessionList() = %v", sessions)
+	}
+	want := []string{"session.list", "auth.login", "session.list"}
+	if got := f.methods(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls %v, want %v", got, want)
+	}
+	if msf.token() != "NEW" {
+		t.Errorf("token = %q, want NEW", msf.token())
+	}
+}
+
+func TestTokenExpiryWithoutCredentials(t *testing.T) {
+	f := newFakeRPC(t, func(args []interface{}) (int, interface{}) {
+		return http.StatusUnauthorized, rpcError("Invalid Authentication Token")
+	})
+	msf, err := NewWithConfig(Config{Host: strings.TrimPrefix(f.URL, "http://"), Token: "EXPIRED"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = msf.SessionList()
+	if rpcErr, ok := IsError(err); !ok || !rpcErr.TokenExpired() {
+		t.Errorf("got %v, want an expired token error", err)
+	}
+	if got := f.methods(); !reflect.DeepEqual(got, []string{"session.list"}) {
+		t.Errorf("calls %v, want a single session.list", got)
+	}
+}
+
+func TestConcurrentTokenExpiry(t *testing.T) {
+	var mu sync.Mutex
+	valid := "EXPIRED"
+	logins := 0
+	f := newFakeRPC(t, func(args []interface{}) (int, interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "auth.login":
+			logins++
+			valid = fmt.Sprintf("TOKEN%d", logins)
+			return 0, map[string]string{"result": "success", "token": valid}
+		case "job.list":
+			if args[1] != valid {
+				return http.StatusUnauthorized, rpcError("Invalid Authentication Token")
+			}
+			return 0, map[string]string{}
+		}
+		return http.StatusInternalServerError, rpcError("Unknown API Call")
+	})
+
+	msf := f.client(t, "OLD")
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := msf.WithContext(context.Background()).JobList()
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if logins != 1 {
+		t.Errorf("logged in %d times, want once", logins)
+	}
+}
@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"gopkg.in/vmihailenco/msgpack.v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// handler answers a decoded call, the first element of args is the method name. A non-zero status is sent as the
+// HTTP status code.
+type handler func(args []interface{}) (status int, res interface{})
+
+// fakeRPC is an msfrpcd stand-in that records the calls it receives.
+type fakeRPC struct {
+	*httptest.Server
+	mu    sync.Mutex
+	calls [][]interface{}
+}
+
+func newFakeRPC(t *testing.T, h handler) *fakeRPC {
+	t.Helper()
+	f := &fakeRPC{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var args []interface{}
+		if err := msgpack.NewDecoder(r.Body).Decode(&args); err != nil || len(args) == 0 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.calls = append(f.calls, args)
+		f.mu.Unlock()
+
+		status, res := h(args)
+		b, err := msgpack.Marshal(res)
+		if err != nil {
+			t.Errorf("encoding response to %v: %v", args[0], err)
+			return
+		}
+		w.Header().Set("Content-Type", "binary/message-pack")
+		if status != 0 {
+			w.WriteHeader(status)
+		}
+		w.Write(b)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// client returns a client for the fake server authenticated with token.
+func (f *fakeRPC) client(t *testing.T, token string) *Metasploit {
+	t.Helper()
+	msf, err := NewWithConfig(Config{Host: strings.TrimPrefix(f.URL, "http://"), User: "msf", Pass: "pass", Token: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msf
+}
+
+// methods returns the method names called so far.
+func (f *fakeRPC) methods() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var methods []string
+	for _, c := range f.calls {
+		methods = append(methods, c[0].(string))
+	}
+	return methods
+}
+
+func rpcError(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"error":         true,
+		"error_class":   "Msf::RPC::Exception",
+		"error_message": message,
+	}
+}
@@ -0,0 +1,171 @@
+package rpc
+
+import (
+	"fmt"
+	"time"
+)
+
+// Session types as reported by session.list.
+const (
+	SessionShell       = "shell"
+	SessionMeterpreter = "meterpreter"
+)
+
+// PollInterval is how often Session.Read asks the server for new output.
+var PollInterval = 500 * time.Millisecond
+
+type sessionReq struct {
+	_msgpack  struct{} `msgpack:",asArray"`
+	Method    string
+	Token     string
+	SessionID uint32
+}
+
+type sessionWriteReq struct {
+	_msgpack  struct{} `msgpack:",asArray"`
+	Method    string
+	Token     string
+	SessionID uint32
+	Data      string
+}
+
+type shellReadRes struct {
+	Seq  uint32 `msgpack:"seq"`
+	Data string `msgpack:"data"`
+}
+
+type shellWriteRes struct {
+	WriteCount string `msgpack:"write_count"`
+}
+
+type meterpreterReadRes struct {
+	Data string `msgpack:"data"`
+}
+
+type resultRes struct {
+	Result string `msgpack:"result"`
+}
+
+// SessionShellRead returns the output a shell session produced since the previous read.
+func (msf *Metasploit) SessionShellRead(id uint32) (string, error) {
+	newReq := func(token string) interface{} {
+		return &sessionReq{Method: "session.shell_read", Token: token, SessionID: id}
+	}
+	var res shellReadRes
+	if err := msf.send(newReq, &res); err != nil {
+		return "", err
+	}
+	return res.Data, nil
+}
+
+// SessionShellWrite writes data to the shell session, commands need a trailing newline to run.
+func (msf *Metasploit) SessionShellWrite(id uint32, data string) error {
+	newReq := func(token string) interface{} {
+		return &sessionWriteReq{Method: "session.shell_write", Token: token, SessionID: id, Data: data}
+	}
+	var res shellWriteRes
+	return msf.send(newReq, &res)
+}
+
+// SessionMeterpreterRead returns the output a meterpreter session produced since the previous read.
+func (msf *Metasploit) SessionMeterpreterRead(id uint32) (string, error) {
+	newReq := func(token string) interface{} {
+		return &sessionReq{Method: "session.meterpreter_read", Token: token, SessionID: id}
+	}
+	var res meterpreterReadRes
+	if err := msf.send(newReq, &res); err != nil {
+		return "", err
+	}
+	return res.Data, nil
+}
+
+// SessionMeterpreterWrite writes data to the meterpreter console of the session.
+func (msf *Metasploit) SessionMeterpreterWrite(id uint32, data string) error {
+	newReq := func(token string) interface{} {
+		return &sessionWriteReq{Method: "session.meterpreter_write", Token: token, SessionID: id, Data: data}
+	}
+	var res resultRes
+	return msf.send(newReq, &res)
+}
+
+// SessionMeterpreterRunSingle runs a single meterpreter command, its output is collected with
+// SessionMeterpreterRead.
+func (msf *Metasploit) SessionMeterpreterRunSingle(id uint32, command string) error {
+	newReq := func(token string) interface{} {
+		return &sessionWriteReq{Method: "session.meterpreter_run_single", Token: token, SessionID: id, Data: command}
+	}
+	var res resultRes
+	return msf.send(newReq, &res)
+}
+
+// Session is an io.ReadWriter over a shell or meterpreter session.
+type Session struct {
+	ID   uint32
+	Type string
+
+	msf *Metasploit
+	buf []byte
+}
+
+// Session looks up the session with the given ID so it can be read from and written to.
+func (msf *Metasploit) Session(id uint32) (*Session, error) {
+	sessions, err := msf.SessionList()
+	if err != nil {
+		return nil, err
+	}
+	s, ok := sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("session %d not found", id)
+	}
+	if s.Type != SessionShell && s.Type != SessionMeterpreter {
+		return nil, fmt.Errorf("session %d has unsupported type %q", id, s.Type)
+	}
+	return &Session{ID: id, Type: s.Type, msf: msf}, nil
+}
+
+func (s *Session) read() (string, error) {
+	if s.Type == SessionMeterpreter {
+		return s.msf.SessionMeterpreterRead(s.ID)
+	}
+	return s.msf.SessionShellRead(s.ID)
+}
+
+// Read blocks until the session produced output, polling every PollInterval.
+func (s *Session) Read(p []byte) (int, error) {
+	for len(s.buf) == 0 {
+		data, err := s.read()
+		if err != nil {
+			return 0, err
+		}
+		if data == "" {
+			time.Sleep(PollInterval)
+			continue
+		}
+		s.buf = []byte(data)
+	}
+
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
+
+func (s *Session) Write(p []byte) (int, error) {
+	var err error
+	if s.Type == SessionMeterpreter {
+		err = s.msf.SessionMeterpreterWrite(s.ID, string(p))
+	} else {
+		err = s.msf.SessionShellWrite(s.ID, string(p))
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// RunSingle runs one meterpreter command on the session.
+func (s *Session) RunSingle(command string) error {
+	if s.Type != SessionMeterpreter {
+		return fmt.Errorf("session %d is not a meterpreter session", s.ID)
+	}
+	return s.msf.SessionMeterpreterRunSingle(s.ID, command)
+}
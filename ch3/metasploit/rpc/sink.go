@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultWebhookTimeout bounds each webhook call, so a hanging chat bridge can't hold up the other sinks.
+const DefaultWebhookTimeout = 10 * time.Second
+
+// Sink receives the events of a Watcher, e.g. to forward them to a chat bridge.
+type Sink interface {
+	Send(e Event) error
+}
+
+// JSONLinesSink writes each event as a single line of JSON.
+type JSONLinesSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{enc: json.NewEncoder(w)}
+}
+
+func (s *JSONLinesSink) Send(e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(e)
+}
+
+// WebhookSink posts each event as JSON to URL.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{URL: url, Client: &http.Client{Timeout: DefaultWebhookTimeout}}
+}
+
+func (s *WebhookSink) Send(e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	res, err := s.Client.Post(s.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook %s answered %s", s.URL, res.Status)
+	}
+	return nil
+}
+
+// Forward sends every event to all sinks until events is closed. A failing sink doesn't stop delivery, its error
+// is passed to onError if that is not nil.
+func Forward(events <-chan Event, onError func(error), sinks ...Sink) {
+	for e := range events {
+		for _, sink := range sinks {
+			if err := sink.Send(e); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
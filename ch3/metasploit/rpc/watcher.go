@@ -0,0 +1,166 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultMaxBackoff caps the delay between polls while session.list or job.list keep failing.
+const DefaultMaxBackoff = 5 * time.Minute
+
+type EventType string
+
+const (
+	SessionOpened EventType = "session_opened"
+	SessionClosed EventType = "session_closed"
+	JobStarted    EventType = "job_started"
+	JobFinished   EventType = "job_finished"
+)
+
+// Event describes a change between two polls. Session is set for session events and Job for job events.
+type Event struct {
+	Type    EventType       `json:"type"`
+	Time    time.Time       `json:"time"`
+	Session *SessionListRes `json:"session,omitempty"`
+	Job     *Job            `json:"job,omitempty"`
+}
+
+// Watcher polls session.list and job.list and reports the differences as events.
+type Watcher struct {
+	Interval time.Duration
+	// EmitInitial reports the sessions and jobs found by the first poll as opened and started, otherwise they
+	// only form the baseline.
+	EmitInitial bool
+	// OnError is called with every failed poll, if it is not nil. Polling continues with the delay doubling up to
+	// MaxBackoff, or DefaultMaxBackoff if that is zero, and returns to Interval after the next successful poll.
+	OnError    func(error)
+	MaxBackoff time.Duration
+
+	msf      *Metasploit
+	sessions map[uint32]SessionListRes
+	jobs     map[uint32]string
+
+	mu  sync.Mutex
+	err error
+}
+
+func NewWatcher(msf *Metasploit, interval time.Duration) *Watcher {
+	return &Watcher{Interval: interval, MaxBackoff: DefaultMaxBackoff, msf: msf}
+}
+
+// Watch starts polling until ctx is cancelled. The returned channel is closed when polling stops, after which Err
+// reports why.
+func (w *Watcher) Watch(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		err := w.run(ctx, events)
+		w.mu.Lock()
+		w.err = err
+		w.mu.Unlock()
+	}()
+	return events
+}
+
+// Err returns the error that stopped the watcher, or nil while it is running.
+func (w *Watcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *Watcher) run(ctx context.Context, events chan<- Event) error {
+	if w.Interval <= 0 {
+		return fmt.Errorf("msf rpc: watcher interval must be positive, got %s", w.Interval)
+	}
+	msf := w.msf.WithContext(ctx)
+	maxDelay := w.MaxBackoff
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxBackoff
+	}
+	if maxDelay < w.Interval {
+		maxDelay = w.Interval
+	}
+
+	first := true
+	delay := w.Interval
+	for {
+		diff, err := w.poll(msf, first && !w.EmitInitial)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if w.OnError != nil {
+				w.OnError(err)
+			}
+			if delay *= 2; delay > maxDelay {
+				delay = maxDelay
+			}
+		} else {
+			first = false
+			delay = w.Interval
+		}
+
+		for _, e := range diff {
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// poll fetches the current state and returns the events since the previous poll. If baseline is set the state is
+// only recorded.
+func (w *Watcher) poll(msf *Metasploit, baseline bool) ([]Event, error) {
+	sessions, err := msf.SessionList()
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := msf.JobList()
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	now := time.Now()
+	if !baseline {
+		for id, s := range sessions {
+			if _, ok := w.sessions[id]; !ok {
+				s := s
+				events = append(events, Event{Type: SessionOpened, Time: now, Session: &s})
+			}
+		}
+		for id, s := range w.sessions {
+			if _, ok := sessions[id]; !ok {
+				s := s
+				events = append(events, Event{Type: SessionClosed, Time: now, Session: &s})
+			}
+		}
+		for id, name := range jobs {
+			if _, ok := w.jobs[id]; !ok {
+				events = append(events, Event{Type: JobStarted, Time: now, Job: &Job{id, name}})
+			}
+		}
+		for id, name := range w.jobs {
+			if _, ok := jobs[id]; !ok {
+				events = append(events, Event{Type: JobFinished, Time: now, Job: &Job{id, name}})
+			}
+		}
+	}
+
+	w.sessions = sessions
+	w.jobs = jobs
+	return events, nil
+}
@@ -0,0 +1,112 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	var polls int32
+	f := newFakeRPC(t, func(args []interface{}) (int, interface{}) {
+		n := atomic.LoadInt32(&polls)
+		switch args[0] {
+		case "session.list":
+			n = atomic.AddInt32(&polls, 1)
+			switch n {
+			case 1:
+				return 0, map[uint32]interface{}{}
+			case 2:
+				// A failing poll must not stop the watcher.
+				return http.StatusInternalServerError, rpcError("database unavailable")
+			default:
+				return 0, map[uint32]interface{}{3: map[string]interface{}{"type": "meterpreter", "session_host": "10.0.1.5"}}
+			}
+		case "job.list":
+			if n >= 3 {
+				return 0, map[string]string{"7": "Exploit: multi/handler"}
+			}
+			return 0, map[string]string{}
+		}
+		return http.StatusInternalServerError, rpcError("Unknown API Call")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := NewWatcher(f.client(t, "permanent"), time.Millisecond)
+	var mu sync.Mutex
+	var errs []error
+	w.OnError = func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+
+	got := map[EventType]Event{}
+	for e := range w.Watch(ctx) {
+		got[e.Type] = e
+		if len(got) == 2 {
+			cancel()
+		}
+	}
+	if err := w.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Err() = %v, want context.Canceled", err)
+	}
+	if s := got[SessionOpened].Session; s == nil || s.ID != 3 || s.SessionHost != "10.0.1.5" {
+		t.Errorf("session_opened = %+v", got[SessionOpened])
+	}
+	if j := got[JobStarted].Job; j == nil || j.ID != 7 {
+		t.Errorf("job_started = %+v", got[JobStarted])
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "database unavailable") {
+		t.Errorf("OnError got %v, want the failed poll", errs)
+	}
+}
+
+func TestWatcherInterval(t *testing.T) {
+	w := NewWatcher(&Metasploit{}, 0)
+	for range w.Watch(context.Background()) {
+	}
+	if w.Err() == nil {
+		t.Error("expected an error for a zero interval")
+	}
+}
+
+func TestEventJSON(t *testing.T) {
+	b, err := json.Marshal(Event{Type: SessionOpened, Session: &SessionListRes{ID: 3, SessionHost: "10.0.1.5"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{`"id":3`, `"session_host":"10.0.1.5"`} {
+		if !strings.Contains(string(b), key) {
+			t.Errorf("%s missing from %s", key, b)
+		}
+	}
+}
+
+func TestWebhookSinkTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s := NewWebhookSink(srv.URL)
+	if s.Client.Timeout != DefaultWebhookTimeout {
+		t.Errorf("timeout = %s, want %s", s.Client.Timeout, DefaultWebhookTimeout)
+	}
+	s.Client.Timeout = 50 * time.Millisecond
+	if err := s.Send(Event{Type: JobStarted}); err == nil {
+		t.Error("expected a timeout from a hanging webhook")
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"github.com/bilalcaliskan/blackhat-go/ch3/shodan/shodan"
+	"github.com/bilalcaliskan/blackhat-go/internal/output"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func runSearch(args []string) error {
+	fs, opts := newFlagSet("search")
+	defer opts.close()
+	pages := fs.Int("pages", 1, "number of result pages to fetch, 0 fetches every page")
+	maxCredits := fs.Int("max-credits", 1, "refuse to run if the search would spend more query credits, -1 disables the limit")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: shodan search [flags] query")
+	}
+	s, err := opts.client()
+	if err != nil {
+		return err
+	}
+	q := strings.Join(fs.Args(), " ")
+
+	if *pages <= 0 {
+		count, err := s.HostCount(q)
+		if err != nil {
+			return err
+		}
+		*pages = shodan.PagesFor(count.Total)
+	}
+	if _, err := s.CheckCredits(q, *pages, *maxCredits); err != nil {
+		return err
+	}
+
+	var matches []shodan.Host
+	for page := 1; page <= *pages; page++ {
+		res, err := s.HostSearchPage(q, page)
+		if err != nil {
+			return err
+		}
+		matches = append(matches, res.Matches...)
+		if len(res.Matches) < shodan.PageSize {
+			break
+		}
+	}
+
+	return output.New(opts.format).Write(matches, hostHeader, hostRows(matches))
+}
+
+func runHost(args []string) error {
+	fs, opts := newFlagSet("host")
+	defer opts.close()
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: shodan host [flags] ip")
+	}
+	s, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	info, err := s.Host(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return output.New(opts.format).Write(info, hostHeader, hostRows(info.Data))
+}
+
+func runCount(args []string) error {
+	fs, opts := newFlagSet("count")
+	defer opts.close()
+	facets := fs.String("facets", "", "comma separated list of facets, e.g. org,port:10")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: shodan count [flags] query")
+	}
+	s, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	if *facets != "" {
+		names = strings.Split(*facets, ",")
+	}
+	count, err := s.HostCount(strings.Join(fs.Args(), " "), names...)
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{{"total", "", strconv.Itoa(count.Total)}}
+	for _, name := range sortedKeys(count.Facets) {
+		for _, f := range count.Facets[name] {
+			rows = append(rows, []string{name, fmt.Sprint(f.Value), strconv.Itoa(f.Count)})
+		}
+	}
+	return output.New(opts.format).Write(count, []string{"FACET", "VALUE", "COUNT"}, rows)
+}
+
+func runDNS(args []string) error {
+	fs, opts := newFlagSet("dns")
+	defer opts.close()
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: shodan dns [flags] hostname|ip...")
+	}
+	s, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	var names, ips []string
+	for _, arg := range fs.Args() {
+		if net.ParseIP(arg) != nil {
+			ips = append(ips, arg)
+		} else {
+			names = append(names, arg)
+		}
+	}
+
+	result := make(map[string][]string)
+	if len(names) > 0 {
+		resolved, err := s.DNSResolve(names...)
+		if err != nil {
+			return err
+		}
+		for name, ip := range resolved {
+			result[name] = []string{ip}
+		}
+	}
+	if len(ips) > 0 {
+		reversed, err := s.DNSReverse(ips...)
+		if err != nil {
+			return err
+		}
+		for ip, hostnames := range reversed {
+			result[ip] = hostnames
+		}
+	}
+
+	var rows [][]string
+	for _, key := range sortedKeys(result) {
+		rows = append(rows, []string{key, strings.Join(result[key], ",")})
+	}
+	return output.New(opts.format).Write(result, []string{"QUERY", "RESULT"}, rows)
+}
+
+func runInfo(args []string) error {
+	fs, opts := newFlagSet("info")
+	defer opts.close()
+	fs.Parse(args)
+	s, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	info, err := s.APIInfo()
+	if err != nil {
+		return err
+	}
+	rows := [][]string{
+		{"plan", info.Plan},
+		{"query_credits", strconv.Itoa(info.QueryCredits)},
+		{"scan_credits", strconv.Itoa(info.ScanCredits)},
+		{"https", strconv.FormatBool(info.HTTPS)},
+		{"telnet", strconv.FormatBool(info.Telnet)},
+		{"unlocked", strconv.FormatBool(info.Unlocked)},
+	}
+	return output.New(opts.format).Write(info, []string{"KEY", "VALUE"}, rows)
+}
+
+var hostHeader = []string{"IP", "PORT", "TRANSPORT", "PRODUCT", "VERSION", "ORG", "VULNS"}
+
+func hostRows(hosts []shodan.Host) [][]string {
+	rows := make([][]string, 0, len(hosts))
+	for _, host := range hosts {
+		rows = append(rows, []string{
+			host.IPString,
+			strconv.Itoa(host.Port),
+			host.Transport,
+			host.Product,
+			host.Version,
+			host.Org,
+			strings.Join(host.CVEs(), ","),
+		})
+	}
+	return rows
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string][]shodan.Facet:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string][]string:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
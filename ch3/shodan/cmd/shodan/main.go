@@ -1,32 +1,116 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/bilalcaliskan/blackhat-go/ch3/shodan/shodan"
+	"github.com/bilalcaliskan/blackhat-go/internal/httpclient"
 	"log"
+	"net/http"
 	"os"
+	"time"
 )
 
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"search", "search [flags] query", runSearch},
+	{"host", "host [flags] ip", runHost},
+	{"count", "count [flags] query", runCount},
+	{"dns", "dns [flags] hostname|ip...", runDNS},
+	{"info", "info [flags]", runInfo},
+	{"verify", "verify [flags] query | -input export.json", runVerify},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: shodan <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", c.usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun 'shodan <command> -h' for the flags of a command.")
+}
+
 func main() {
-	if len(os.Args) != 2 {
-		log.Fatalln("Usage: shodan searchterm")
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				log.Fatalln(err)
+			}
+			return
+		}
 	}
+	usage()
+	os.Exit(2)
+}
+
+// options holds the flags shared by every command.
+type options struct {
+	format  string
+	noCache bool
+	refresh bool
+	ttl     time.Duration
+	http    *httpclient.Flags
+	hc      *http.Client
+}
+
+// newFlagSet returns a flag set for the named command with the shared flags already defined.
+func newFlagSet(name string) (*flag.FlagSet, *options) {
+	opts := &options{}
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&opts.format, "format", "table", "output format: table, json or csv")
+	fs.BoolVar(&opts.noCache, "no-cache", false, "neither read nor write the local result cache")
+	fs.BoolVar(&opts.refresh, "refresh", false, "ignore cached results but store the fresh ones")
+	fs.DurationVar(&opts.ttl, "cache-ttl", shodan.DefaultTTL, "how long cached results are used")
+	opts.http = (&httpclient.Config{}).RegisterFlags(fs)
+	return fs, opts
+}
+
+// client returns a Shodan client for SHODAN_API_KEY with the cache and HTTP client configured from the flags.
+func (o *options) client() (*shodan.Client, error) {
 	apiKey := os.Getenv("SHODAN_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("missing required environment variable SHODAN_API_KEY")
+	}
 	s := shodan.New(apiKey)
-	info, err := s.APIInfo()
+	hc, err := o.http.Client()
 	if err != nil {
-		log.Panicln(err)
+		return nil, err
+	}
+	o.hc = hc
+	s.UseHTTPClient(hc)
+	if o.noCache {
+		return s, nil
 	}
-	fmt.Printf(
-		"Query Credits: %d\nScan Credits:  %d\n\n",
-		info.QueryCredits,
-		info.ScanCredits)
 
-	hostSearch, err := s.HostSearch(os.Args[1])
+	dir, err := shodan.DefaultCacheDir()
 	if err != nil {
-		log.Panicln(err)
+		return nil, err
+	}
+	cache, err := shodan.NewCache(dir, o.ttl)
+	if err != nil {
+		return nil, err
+	}
+	cache.Refresh = o.refresh
+	s.UseCache(cache)
+	return s, nil
+}
+
+// close writes the HAR file requested by the flags, if the command created a client.
+func (o *options) close() {
+	if o.hc == nil {
+		return
 	}
-	for _, host := range hostSearch.Matches {
-		fmt.Printf("%18s%8d\n", host.IPString, host.Port)
+	if err := httpclient.Close(o.hc); err != nil {
+		log.Printf("writing HAR file: %v", err)
 	}
 }
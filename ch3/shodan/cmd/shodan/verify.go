@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/bilalcaliskan/blackhat-go/ch2/scanner"
+	"github.com/bilalcaliskan/blackhat-go/ch3/shodan/shodan"
+	"github.com/bilalcaliskan/blackhat-go/internal/output"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	statusLive         = "live"
+	statusChanged      = "changed"
+	statusGone         = "gone"
+	statusUnverifiable = "unverifiable"
+)
+
+type verification struct {
+	IP        string `json:"ip"`
+	Port      int    `json:"port"`
+	Status    string `json:"status"`
+	Timestamp string `json:"shodan_timestamp"`
+	Shodan    string `json:"shodan_banner"`
+	Banner    string `json:"banner"`
+	Error     string `json:"error,omitempty"`
+}
+
+func runVerify(args []string) error {
+	fs, opts := newFlagSet("verify")
+	defer opts.close()
+	input := fs.String("input", "", "saved JSON export (search, host or a list of matches) instead of a live search")
+	workers := fs.Int("workers", 100, "number of concurrent probes")
+	timeout := fs.Duration("timeout", 3*time.Second, "connect timeout for each probe")
+	maxCredits := fs.Int("max-credits", 1, "refuse to run if the search would spend more query credits, -1 disables the limit")
+	fs.Parse(args)
+
+	var hosts []shodan.Host
+	switch {
+	case *input != "":
+		data, err := ioutil.ReadFile(*input)
+		if err != nil {
+			return err
+		}
+		if hosts, err = loadExport(data); err != nil {
+			return err
+		}
+	case fs.NArg() > 0:
+		s, err := opts.client()
+		if err != nil {
+			return err
+		}
+		q := strings.Join(fs.Args(), " ")
+		if _, err := s.CheckCredits(q, 1, *maxCredits); err != nil {
+			return err
+		}
+		res, err := s.HostSearch(q)
+		if err != nil {
+			return err
+		}
+		hosts = res.Matches
+	default:
+		return fmt.Errorf("usage: shodan verify [flags] query | shodan verify -input export.json")
+	}
+
+	sc := scanner.New(*workers, *timeout)
+	sc.GrabBanners = true
+	verified := verifyHosts(sc, hosts)
+	var rows [][]string
+	for _, v := range verified {
+		rows = append(rows, []string{v.IP, strconv.Itoa(v.Port), v.Status, v.Timestamp, v.Shodan, v.Banner})
+	}
+	return output.New(opts.format).Write(verified, []string{"IP", "PORT", "STATUS", "SEEN", "SHODAN", "NOW"}, rows)
+}
+
+// verifyHosts probes the services of hosts with sc and returns their verifications in the same order.
+func verifyHosts(sc *scanner.Scanner, hosts []shodan.Host) []verification {
+	// The scanner only speaks TCP, UDP services are listed as unverifiable instead of being probed.
+	var targets []scanner.Target
+	for _, host := range hosts {
+		if tcp(host) {
+			targets = append(targets, scanner.Target{Host: host.IPString, Port: host.Port})
+		}
+	}
+	results := sc.Scan(targets)
+
+	var verified []verification
+	for _, host := range hosts {
+		if tcp(host) {
+			verified = append(verified, verify(host, results[0]))
+			results = results[1:]
+		} else {
+			verified = append(verified, unverifiable(host))
+		}
+	}
+	return verified
+}
+
+func tcp(host shodan.Host) bool {
+	return host.Transport == "" || host.Transport == "tcp"
+}
+
+// unverifiable reports a service the TCP scanner can't probe.
+func unverifiable(host shodan.Host) verification {
+	return verification{
+		IP:        host.IPString,
+		Port:      host.Port,
+		Status:    statusUnverifiable,
+		Timestamp: host.Timestamp,
+		Shodan:    firstLine(host.Data),
+		Error:     fmt.Sprintf("%s services can't be probed", host.Transport),
+	}
+}
+
+// verify compares a probe against what Shodan recorded. A service is considered changed if it greeted the
+// scanner with a banner that differs from the first line of the banner Shodan collected. Only that first line is
+// compared, the scanner reads a single line and Shodan's data often continues with protocol specific details, so
+// a change further down, e.g. in the HTTP headers, goes unnoticed.
+func verify(host shodan.Host, res scanner.Result) verification {
+	v := verification{
+		IP:        host.IPString,
+		Port:      host.Port,
+		Timestamp: host.Timestamp,
+		Shodan:    firstLine(host.Data),
+		Banner:    res.Banner,
+	}
+
+	switch {
+	case !res.Open:
+		v.Status = statusGone
+		if res.Err != nil {
+			v.Error = res.Err.Error()
+		}
+	case v.Banner != "" && v.Shodan != "" && v.Banner != v.Shodan:
+		v.Status = statusChanged
+	default:
+		v.Status = statusLive
+	}
+	return v
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// loadExport reads the output of 'shodan search -format json', 'shodan host -format json' or a raw API response.
+func loadExport(data []byte) ([]shodan.Host, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var hosts []shodan.Host
+		if err := json.Unmarshal(data, &hosts); err != nil {
+			return nil, err
+		}
+		return hosts, nil
+	}
+
+	var export struct {
+		Matches []shodan.Host `json:"matches"`
+		Data    []shodan.Host `json:"data"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+	return append(export.Matches, export.Data...), nil
+}
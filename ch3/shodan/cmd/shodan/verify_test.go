@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"github.com/bilalcaliskan/blackhat-go/ch2/scanner"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// banner starts a TCP listener that greets every connection with greeting and returns its port.
+func banner(t *testing.T, greeting string) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			fmt.Fprint(conn, greeting)
+			conn.Close()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+// closedPort returns a port nothing listens on.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	return port
+}
+
+func TestVerifyHosts(t *testing.T) {
+	same := banner(t, "SSH-2.0-OpenSSH_8.9\r\n")
+	changed := banner(t, "220 mail.example.com ESMTP Postfix\r\n")
+	gone := closedPort(t)
+
+	tests := []struct {
+		name      string
+		port      int
+		transport string
+		data      string
+		status    string
+	}{
+		{"same banner", same, "tcp", `SSH-2.0-OpenSSH_8.9\r\nKey type: ssh-rsa`, statusLive},
+		{"changed banner", changed, "tcp", `220 mail.example.com ESMTP Exim 4.96\r\n`, statusChanged},
+		{"closed port", gone, "", `HTTP/1.1 200 OK\r\n`, statusGone},
+		{"udp", 161, "udp", `SNMP\n`, statusUnverifiable},
+	}
+
+	// The hosts go through loadExport like a saved 'shodan search -format json'.
+	var matches []string
+	for _, tt := range tests {
+		matches = append(matches, fmt.Sprintf(
+			`{"ip_str": "127.0.0.1", "port": %d, "transport": %q, "data": "%s", "timestamp": "2026-10-01T00:00:00"}`,
+			tt.port, tt.transport, tt.data))
+	}
+	hosts, err := loadExport([]byte(`{"matches": [` + strings.Join(matches, ",") + `]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sc := scanner.New(4, time.Second)
+	sc.GrabBanners = true
+	sc.BannerWait = time.Second
+	verified := verifyHosts(sc, hosts)
+	if len(verified) != len(tests) {
+		t.Fatalf("%d verifications, want %d", len(verified), len(tests))
+	}
+	for i, tt := range tests {
+		if v := verified[i]; v.Port != tt.port || v.Status != tt.status {
+			t.Errorf("%s: got port %d %s, want port %d %s (%+v)", tt.name, v.Port, v.Status, tt.port, tt.status, v)
+		}
+	}
+	if v := verified[1]; v.Shodan != "220 mail.example.com ESMTP Exim 4.96" || v.Banner != "220 mail.example.com ESMTP Postfix" {
+		t.Errorf("changed banner compared %q with %q", v.Shodan, v.Banner)
+	}
+}
@@ -1,11 +1,5 @@
 package shodan
 
-import (
-	"encoding/json"
-	"fmt"
-	"net/http"
-)
-
 type APIInfo struct {
 	QueryCredits int    `json:"query_credits"`
 	ScanCredits  int    `json:"scan_credits"`
@@ -16,14 +10,8 @@ type APIInfo struct {
 }
 
 func (s *Client) APIInfo() (*APIInfo, error) {
-	res, err := http.Get(fmt.Sprintf("%s/api-info?key=%s", BaseURL, s.apiKey))
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-
 	var ret APIInfo
-	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+	if err := s.get("/api-info", nil, &ret); err != nil {
 		return nil, err
 	}
 	return &ret, nil
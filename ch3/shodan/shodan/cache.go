@@ -0,0 +1,133 @@
+package shodan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultTTL is how long cached responses are served before they are fetched again.
+const DefaultTTL = 24 * time.Hour
+
+// Cache stores API responses on disk, one JSON file per normalized request.
+type Cache struct {
+	Dir string
+	TTL time.Duration
+	// Refresh ignores existing entries but still stores fresh responses.
+	Refresh bool
+}
+
+type cacheEntry struct {
+	Path    string          `json:"path"`
+	Params  string          `json:"params"`
+	Fetched time.Time       `json:"fetched"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// DefaultCacheDir returns the shodan directory under the user's cache directory, e.g. ~/.cache/shodan.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "shodan"), nil
+}
+
+func NewCache(dir string, ttl time.Duration) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Cache{Dir: dir, TTL: ttl}, nil
+}
+
+// normalize builds the cache key input for a request, so that queries differing only in whitespace share an entry.
+// Whitespace inside quoted values is significant, title:"a  b" and title:"a b" are different queries.
+func normalize(params url.Values) string {
+	norm := url.Values{}
+	for k, v := range params {
+		if k == "key" {
+			continue
+		}
+		for _, value := range v {
+			norm.Add(k, collapseSpace(value))
+		}
+	}
+	return norm.Encode()
+}
+
+// collapseSpace trims value and replaces runs of whitespace outside double quotes with a single space.
+func collapseSpace(value string) string {
+	var b strings.Builder
+	var quoted, space bool
+	for _, r := range strings.TrimSpace(value) {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case !quoted && unicode.IsSpace(r):
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (c *Cache) file(path string, params url.Values) string {
+	sum := sha256.Sum256([]byte(path + "?" + normalize(params)))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load returns the cached body for a request. Expired entries are only returned if stale is true.
+func (c *Cache) load(path string, params url.Values, stale bool) ([]byte, bool) {
+	data, err := ioutil.ReadFile(c.file(path, params))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	if !stale && c.TTL > 0 && time.Since(entry.Fetched) > c.TTL {
+		return nil, false
+	}
+	return entry.Body, true
+}
+
+func (c *Cache) store(path string, params url.Values, body []byte) error {
+	data, err := json.Marshal(&cacheEntry{
+		Path:    path,
+		Params:  normalize(params),
+		Fetched: time.Now(),
+		Body:    body,
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(c.Dir, ".entry-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.file(path, params))
+}
@@ -0,0 +1,84 @@
+package shodan
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	var calls int32
+	s := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"ip_str": "8.8.8.8", "ports": [53]}`))
+	})
+	cache, err := NewCache(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.UseCache(cache)
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.Host("8.8.8.8"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("%d requests, want the second answered from the cache", calls)
+	}
+}
+
+func TestCacheStoreFailure(t *testing.T) {
+	s := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ip_str": "8.8.8.8", "ports": [53]}`))
+	})
+	// A file where the cache directory should be makes every store fail.
+	dir := filepath.Join(t.TempDir(), "cache")
+	if err := ioutil.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s.UseCache(&Cache{Dir: dir, TTL: time.Hour})
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	host, err := s.Host("8.8.8.8")
+	if err != nil {
+		t.Fatalf("a failing cache failed the request: %v", err)
+	}
+	if host.IPString != "8.8.8.8" {
+		t.Errorf("got %+v", host)
+	}
+	if !strings.Contains(logged.String(), "caching /shodan/host/8.8.8.8") {
+		t.Errorf("store failure not logged: %q", logged.String())
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"apache  port:80", " apache port:80\t", true},
+		{`title:"a b"  country:DE`, `title:"a b" country:DE`, true},
+		{`title:"a  b"`, `title:"a b"`, false},
+		{`title:"a b`, `title:"a  b`, false},
+		{"apache", "Apache", false},
+	}
+	for _, tt := range tests {
+		a := normalize(url.Values{"query": {tt.a}, "key": {"one"}})
+		b := normalize(url.Values{"query": {tt.b}, "key": {"two"}})
+		if (a == b) != tt.same {
+			t.Errorf("normalize(%q) = %q, normalize(%q) = %q, want same: %v", tt.a, a, tt.b, b, tt.same)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package shodan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PageSize is the number of matches Shodan returns for each page of a search.
+const PageSize = 100
+
+// SearchCost returns the number of query credits a search for q spends when fetching the given number of pages.
+// Shodan deducts one credit per page if the query contains a filter, and one credit for every page after the first.
+func SearchCost(q string, pages int) int {
+	if pages <= 0 {
+		return 0
+	}
+	if hasFilter(q) {
+		return pages
+	}
+	return pages - 1
+}
+
+// PagesFor returns the number of pages needed to fetch total results.
+func PagesFor(total int) int {
+	return (total + PageSize - 1) / PageSize
+}
+
+func hasFilter(q string) bool {
+	for _, term := range strings.Fields(q) {
+		if i := strings.Index(term, ":"); i > 0 && i < len(term)-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// CreditError is returned by CheckCredits when a search would spend more query credits than allowed.
+type CreditError struct {
+	Cost      int
+	Max       int
+	Available int
+}
+
+func (e *CreditError) Error() string {
+	if e.Max >= 0 && e.Cost > e.Max {
+		return fmt.Sprintf("shodan: search needs %d query credits, limit is %d", e.Cost, e.Max)
+	}
+	return fmt.Sprintf("shodan: search needs %d query credits, only %d available", e.Cost, e.Available)
+}
+
+// CheckCredits verifies that fetching the given number of pages for q stays within max credits and within the
+// query credits left on the account. Pages that will be served from the cache are free. A negative max disables
+// the limit.
+func (s *Client) CheckCredits(q string, pages, max int) (int, error) {
+	cost := 0
+	for page := 1; page <= pages; page++ {
+		if !s.searchCached(q, page) {
+			cost += SearchCost(q, page) - SearchCost(q, page-1)
+		}
+	}
+	if cost == 0 {
+		return 0, nil
+	}
+	if max >= 0 && cost > max {
+		return cost, &CreditError{Cost: cost, Max: max}
+	}
+
+	info, err := s.APIInfo()
+	if err != nil {
+		return cost, err
+	}
+	if cost > info.QueryCredits {
+		return cost, &CreditError{Cost: cost, Max: max, Available: info.QueryCredits}
+	}
+	return cost, nil
+}
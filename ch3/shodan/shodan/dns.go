@@ -0,0 +1,37 @@
+package shodan
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DNSResolve looks up the IP address of each hostname. Hostnames without a record map to an empty string.
+func (s *Client) DNSResolve(hostnames ...string) (map[string]string, error) {
+	params := url.Values{"hostnames": {strings.Join(hostnames, ",")}}
+
+	ret := make(map[string]*string)
+	if err := s.get("/dns/resolve", params, &ret); err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]string, len(ret))
+	for name, ip := range ret {
+		if ip != nil {
+			resolved[name] = *ip
+		} else {
+			resolved[name] = ""
+		}
+	}
+	return resolved, nil
+}
+
+// DNSReverse looks up the hostnames that have been defined for each IP address.
+func (s *Client) DNSReverse(ips ...string) (map[string][]string, error) {
+	params := url.Values{"ips": {strings.Join(ips, ",")}}
+
+	ret := make(map[string][]string)
+	if err := s.get("/dns/reverse", params, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,59 @@
+package shodan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when Shodan answers with a non-2xx status code. Message holds the "error" field of
+// the response body, or the raw body if it is not JSON.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("shodan: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("shodan: %d %s", e.StatusCode, e.Message)
+}
+
+// Unauthorized reports whether the API key was rejected.
+func (e *APIError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// RateLimited reports whether the request was still throttled after all retries.
+func (e *APIError) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsAPIError unwraps err into an *APIError if possible.
+func IsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	ok := errors.As(err, &apiErr)
+	return apiErr, ok
+}
+
+func newAPIError(res *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: res.StatusCode}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return apiErr
+	}
+
+	var payload struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Error != "" {
+		apiErr.Message = payload.Error
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
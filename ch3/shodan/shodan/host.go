@@ -3,7 +3,10 @@ package shodan
 import (
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type HostLocation struct {
@@ -19,36 +22,214 @@ type HostLocation struct {
 	Latitude     float32 `json:"latitude"`
 }
 
+type HostHTTP struct {
+	Status     int                          `json:"status"`
+	Title      string                       `json:"title"`
+	Server     string                       `json:"server"`
+	Host       string                       `json:"host"`
+	Location   string                       `json:"location"`
+	HTMLHash   int64                        `json:"html_hash"`
+	Robots     string                       `json:"robots"`
+	WAF        string                       `json:"waf"`
+	Components map[string]HostHTTPComponent `json:"components"`
+}
+
+type HostHTTPComponent struct {
+	Categories []string `json:"categories"`
+}
+
+type SSLName struct {
+	CommonName         string `json:"CN"`
+	Organization       string `json:"O"`
+	OrganizationalUnit string `json:"OU"`
+	Country            string `json:"C"`
+	State              string `json:"ST"`
+	Locality           string `json:"L"`
+}
+
+type SSLFingerprint struct {
+	SHA1   string `json:"sha1"`
+	SHA256 string `json:"sha256"`
+}
+
+type SSLPublicKey struct {
+	Type string `json:"type"`
+	Bits int    `json:"bits"`
+}
+
+type SSLCertificate struct {
+	Subject     SSLName        `json:"subject"`
+	Issuer      SSLName        `json:"issuer"`
+	Issued      string         `json:"issued"`
+	Expires     string         `json:"expires"`
+	Expired     bool           `json:"expired"`
+	Serial      json.Number    `json:"serial"`
+	SigAlg      string         `json:"sig_alg"`
+	Version     int            `json:"version"`
+	Fingerprint SSLFingerprint `json:"fingerprint"`
+	PublicKey   SSLPublicKey   `json:"pubkey"`
+}
+
+type SSLCipher struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Bits    int    `json:"bits"`
+}
+
+type HostSSL struct {
+	Cert     SSLCertificate `json:"cert"`
+	Cipher   SSLCipher      `json:"cipher"`
+	Versions []string       `json:"versions"`
+	Chain    []string       `json:"chain"`
+	ALPN     []string       `json:"alpn"`
+	JARM     string         `json:"jarm"`
+}
+
+type Vuln struct {
+	Verified   bool     `json:"verified"`
+	CVSS       float32  `json:"cvss"`
+	Summary    string   `json:"summary"`
+	References []string `json:"references"`
+}
+
 type Host struct {
-	OS        string       `json:"os"`
-	Timestamp string       `json:"timestamp"`
-	ISP       string       `json:"isp"`
-	ASN       string       `json:"asn"`
-	Hostnames []string     `json:"hostnames"`
-	Location  HostLocation `json:"location"`
-	IP        int64        `json:"ip"`
-	Domains   []string     `json:"domains"`
-	Org       string       `json:"org"`
-	Data      string       `json:"data"`
-	Port      int          `json:"port"`
-	IPString  string       `json:"ip_str"`
+	OS        string          `json:"os"`
+	Timestamp string          `json:"timestamp"`
+	ISP       string          `json:"isp"`
+	ASN       string          `json:"asn"`
+	Hostnames []string        `json:"hostnames"`
+	Location  HostLocation    `json:"location"`
+	IP        int64           `json:"ip"`
+	Domains   []string        `json:"domains"`
+	Org       string          `json:"org"`
+	Data      string          `json:"data"`
+	Port      int             `json:"port"`
+	IPString  string          `json:"ip_str"`
+	Transport string          `json:"transport"`
+	Product   string          `json:"product"`
+	Version   string          `json:"version"`
+	CPE       []string        `json:"cpe"`
+	CPE23     []string        `json:"cpe23"`
+	Tags      []string        `json:"tags"`
+	Vulns     map[string]Vuln `json:"vulns"`
+	HTTP      *HostHTTP       `json:"http"`
+	SSL       *HostSSL        `json:"ssl"`
+}
+
+// CVEs returns the sorted list of vulnerability IDs Shodan reported for the service.
+func (h Host) CVEs() []string {
+	ids := make([]string, 0, len(h.Vulns))
+	for id := range h.Vulns {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// HasCVE reports whether the service is affected by the given vulnerability ID, case-insensitively.
+func (h Host) HasCVE(id string) bool {
+	for cve := range h.Vulns {
+		if strings.EqualFold(cve, id) {
+			return true
+		}
+	}
+	return false
 }
 
 type HostSearch struct {
 	Matches []Host `json:"matches"`
+	Total   int    `json:"total"`
+}
+
+// Filter returns the matches for which keep returns true.
+func (h *HostSearch) Filter(keep func(Host) bool) []Host {
+	var ret []Host
+	for _, host := range h.Matches {
+		if keep(host) {
+			ret = append(ret, host)
+		}
+	}
+	return ret
 }
 
 func (s *Client) HostSearch(q string) (*HostSearch, error) {
-	res, err := http.Get(
-		fmt.Sprintf("%s/shodan/host/search?key=%s&query=%s", BaseURL, s.apiKey, q),
-	)
-	if err != nil {
+	return s.HostSearchPage(q, 1)
+}
+
+// HostSearchPage returns a single page of search results, Shodan returns PageSize matches per page.
+func (s *Client) HostSearchPage(q string, page int) (*HostSearch, error) {
+	var ret HostSearch
+	if err := s.get("/shodan/host/search", searchParams(q, page), &ret); err != nil {
 		return nil, err
 	}
-	defer res.Body.Close()
 
-	var ret HostSearch
-	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+	return &ret, nil
+}
+
+func searchParams(q string, page int) url.Values {
+	params := url.Values{"query": {q}}
+	if page > 1 {
+		params.Set("page", strconv.Itoa(page))
+	}
+	return params
+}
+
+// searchCached reports whether a page of search results would be served from the cache.
+func (s *Client) searchCached(q string, page int) bool {
+	if s.cache == nil || s.cache.Refresh {
+		return false
+	}
+	_, ok := s.cache.load("/shodan/host/search", searchParams(q, page), false)
+	return ok
+}
+
+type Facet struct {
+	Count int         `json:"count"`
+	Value interface{} `json:"value"`
+}
+
+type HostCount struct {
+	Total  int                `json:"total"`
+	Facets map[string][]Facet `json:"facets"`
+}
+
+// HostCount returns the number of results for a query without returning any matches. It does not use query credits.
+func (s *Client) HostCount(q string, facets ...string) (*HostCount, error) {
+	params := url.Values{"query": {q}}
+	if len(facets) > 0 {
+		params.Set("facets", strings.Join(facets, ","))
+	}
+
+	var ret HostCount
+	if err := s.get("/shodan/host/count", params, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+type HostInfo struct {
+	IPString    string   `json:"ip_str"`
+	Hostnames   []string `json:"hostnames"`
+	Domains     []string `json:"domains"`
+	Ports       []int    `json:"ports"`
+	Tags        []string `json:"tags"`
+	Vulns       []string `json:"vulns"`
+	OS          string   `json:"os"`
+	Org         string   `json:"org"`
+	ISP         string   `json:"isp"`
+	ASN         string   `json:"asn"`
+	City        string   `json:"city"`
+	CountryName string   `json:"country_name"`
+	CountryCode string   `json:"country_code"`
+	LastUpdate  string   `json:"last_update"`
+	Data        []Host   `json:"data"`
+}
+
+// Host returns all services that have been found on the given IP. It does not use query credits.
+func (s *Client) Host(ip string) (*HostInfo, error) {
+	var ret HostInfo
+	if err := s.get(fmt.Sprintf("/shodan/host/%s", url.PathEscape(ip)), nil, &ret); err != nil {
 		return nil, err
 	}
 
@@ -1,11 +1,175 @@
 package shodan
 
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
 const BaseURL = "https://api.shodan.io"
 
+// RateLimit is the minimum delay between two requests, Shodan allows 1 request per second.
+const RateLimit = time.Second
+
+// MaxRetries is the number of times a request is retried after Shodan answers with 429.
+const MaxRetries = 3
+
+// MaxRetryWait is the longest Retry-After that is waited for, a 429 asking for more is returned as *APIError.
+const MaxRetryWait = time.Minute
+
 type Client struct {
-	apiKey string
+	apiKey    string
+	baseURL   string
+	client    *http.Client
+	cache     *Cache
+	rateLimit time.Duration
+
+	mu   sync.Mutex
+	last time.Time
 }
 
 func New(apiKey string) *Client {
-	return &Client{apiKey: apiKey}
+	return &Client{
+		apiKey:    apiKey,
+		baseURL:   BaseURL,
+		client:    http.DefaultClient,
+		rateLimit: RateLimit,
+	}
+}
+
+// UseBaseURL sends all requests to u instead of BaseURL, e.g. a proxy in front of the API or a test server.
+func (s *Client) UseBaseURL(u string) {
+	s.baseURL = strings.TrimSuffix(u, "/")
+}
+
+// UseHTTPClient sends all requests through c, e.g. one built by the internal httpclient package.
+func (s *Client) UseHTTPClient(c *http.Client) {
+	s.client = c
+}
+
+// UseCache makes the client answer repeated requests from c. Passing nil disables caching.
+func (s *Client) UseCache(c *Cache) {
+	s.cache = c
+}
+
+// cacheable reports whether responses for path can be served from the cache. Account information is always
+// fetched live since it changes with every search.
+func (s *Client) cacheable(path string) bool {
+	return s.cache != nil && path != "/api-info"
+}
+
+// wait blocks until RateLimit has passed since the previous request.
+func (s *Client) wait() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d := s.rateLimit - time.Since(s.last); d > 0 {
+		time.Sleep(d)
+	}
+	s.last = time.Now()
+}
+
+// get issues a GET request against the given path and decodes the JSON response into v. Responses are served
+// from the cache when one is set, and a stale entry is used if Shodan can't be reached. Failing to store a fresh
+// response is logged but doesn't fail the request.
+func (s *Client) get(path string, params url.Values, v interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+
+	if !s.cacheable(path) {
+		body, err := s.fetch(path, params)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(body, v)
+	}
+
+	if !s.cache.Refresh {
+		if body, ok := s.cache.load(path, params, false); ok {
+			return json.Unmarshal(body, v)
+		}
+	}
+
+	body, err := s.fetch(path, params)
+	if err != nil {
+		if _, ok := IsAPIError(err); ok {
+			return err
+		}
+		stale, ok := s.cache.load(path, params, true)
+		if !ok {
+			return err
+		}
+		body = stale
+	} else if err := s.cache.store(path, params, body); err != nil {
+		// The response is fine, a full disk or a read-only cache directory only costs a credit next time.
+		log.Printf("shodan: caching %s: %v", path, err)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// fetch returns the raw response body of a GET request. Non-2xx responses are returned as *APIError, and 429
+// responses are retried after the delay given by Retry-After, or with an exponential backoff without one. A
+// Retry-After longer than MaxRetryWait isn't waited for.
+func (s *Client) fetch(path string, params url.Values) ([]byte, error) {
+	query := url.Values{}
+	for k, v := range params {
+		query[k] = v
+	}
+	query.Set("key", s.apiKey)
+	dest := fmt.Sprintf("%s%s?%s", s.baseURL, path, query.Encode())
+
+	backoff := s.rateLimit
+	for attempt := 0; ; attempt++ {
+		s.wait()
+		res, err := s.client.Get(dest)
+		if err != nil {
+			return nil, err
+		}
+
+		if res.StatusCode == http.StatusTooManyRequests && attempt < MaxRetries {
+			d, ok := retryAfter(res)
+			if !ok {
+				d = backoff
+			}
+			if d <= MaxRetryWait {
+				res.Body.Close()
+				time.Sleep(d)
+				backoff *= 2
+				continue
+			}
+		}
+
+		body, err := read(res)
+		res.Body.Close()
+		return body, err
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	v := res.Header.Get("Retry-After")
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func read(res *http.Response) ([]byte, error) {
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, newAPIError(res)
+	}
+	return ioutil.ReadAll(res.Body)
 }
@@ -0,0 +1,172 @@
+package shodan
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testClient returns a client for a test server that answers every request with h, without rate limiting.
+func testClient(t *testing.T, h http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	s := New("secret")
+	s.UseBaseURL(srv.URL + "/")
+	s.rateLimit = 10 * time.Millisecond
+	return s
+}
+
+func TestAPIInfo(t *testing.T) {
+	s := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api-info" || r.URL.Query().Get("key") != "secret" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Write([]byte(`{"query_credits": 100, "plan": "dev"}`))
+	})
+	info, err := s.APIInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.QueryCredits != 100 || info.Plan != "dev" {
+		t.Errorf("got %+v", info)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		message string
+		check   func(*APIError) bool
+	}{
+		{"unauthorized", http.StatusUnauthorized, `{"error": "Invalid API key"}`, "Invalid API key", (*APIError).Unauthorized},
+		{"non-JSON body", http.StatusBadGateway, "<html>Bad Gateway</html>\n", "<html>Bad Gateway</html>", nil},
+		{"empty body", http.StatusNotFound, "", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+			_, err := s.APIInfo()
+			apiErr, ok := IsAPIError(err)
+			if !ok {
+				t.Fatalf("got %v, want an *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Message != tt.message {
+				t.Errorf("got %d %q, want %d %q", apiErr.StatusCode, apiErr.Message, tt.status, tt.message)
+			}
+			if tt.check != nil && !tt.check(apiErr) {
+				t.Errorf("%v not classified", apiErr)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var calls int32
+	var first time.Time
+	s := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if d := time.Since(first); d < 900*time.Millisecond {
+			t.Errorf("retried after %s, Retry-After asked for 1s", d)
+		}
+		w.Write([]byte(`{"plan": "dev"}`))
+	})
+	if _, err := s.APIInfo(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("%d calls, want 2", calls)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	var calls int32
+	s := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"plan": "dev"}`))
+	})
+	if _, err := s.APIInfo(); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("%d calls, want 3", calls)
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	var calls int32
+	s := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": "Rate limit reached"}`))
+	})
+	_, err := s.APIInfo()
+	apiErr, ok := IsAPIError(err)
+	if !ok || !apiErr.RateLimited() || !strings.Contains(apiErr.Message, "Rate limit") {
+		t.Fatalf("got %v, want a rate limit error", err)
+	}
+	if calls != MaxRetries+1 {
+		t.Errorf("%d calls, want %d", calls, MaxRetries+1)
+	}
+}
+
+func TestRetryAfterTooLong(t *testing.T) {
+	var calls int32
+	s := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": "Rate limit reached"}`))
+	})
+	start := time.Now()
+	_, err := s.APIInfo()
+	if apiErr, ok := IsAPIError(err); !ok || !apiErr.RateLimited() {
+		t.Fatalf("got %v, want a rate limit error", err)
+	}
+	if calls != 1 || time.Since(start) > time.Second {
+		t.Errorf("waited %s for %d calls, want to give up at once", time.Since(start), calls)
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"3", 3 * time.Second, true},
+		{"0", 0, true},
+		{"", 0, false},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, true},
+	}
+	for _, tt := range tests {
+		res := &http.Response{Header: http.Header{"Retry-After": {tt.value}}}
+		got, ok := retryAfter(res)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if d, ok := retryAfter(&http.Response{Header: http.Header{"Retry-After": {future}}}); !ok || d < 59*time.Minute {
+		t.Errorf("retryAfter(%q) = %s, %v", future, d, ok)
+	}
+}
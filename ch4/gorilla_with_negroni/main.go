@@ -1,44 +1,62 @@
 package main
 
 import (
-	"context"
 	"fmt"
+	"log"
 	"net/http"
+	"time"
 
+	"github.com/bilalcaliskan/blackhat-go/ch4/middleware/auth"
 	"github.com/gorilla/mux"
 	"github.com/urfave/negroni"
 )
 
-type badAuth struct {
-	Username string
-	Password string
-}
-
-func (b *badAuth) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-	username := r.URL.Query().Get("username")
-	password := r.URL.Query().Get("password")
-	if username != b.Username || password != b.Password {
+func hello(w http.ResponseWriter, r *http.Request) {
+	username, ok := auth.User(r.Context())
+	if !ok {
 		http.Error(w, "Unauthorized", 401)
 		return
 	}
-	ctx := context.WithValue(r.Context(), "username", username)
-	r = r.WithContext(ctx)
-	next(w, r)
+	fmt.Fprintf(w, "Hi %s\n", username)
 }
 
-func hello(w http.ResponseWriter, r *http.Request) {
-	username := r.Context().Value("username").(string)
-	fmt.Fprintf(w, "Hi %s\n", username)
+// login trades the credentials the request was authenticated with for a session cookie.
+func login(sessions *auth.Sessions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username, _ := auth.User(r.Context())
+		if err := sessions.Login(w, username); err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		fmt.Fprintf(w, "Logged in as %s\n", username)
+	}
+}
+
+func logout(sessions *auth.Sessions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessions.Logout(w, r)
+		fmt.Fprintln(w, "Logged out")
+	}
 }
 
 func main() {
+	sessions := auth.NewSessions(time.Hour)
+	// The example listens on plain HTTP, so the cookie can't be restricted to HTTPS.
+	sessions.Secure = false
+
+	authn := auth.New(
+		auth.NewBasic("bhg", map[string]string{"admin": "password"}),
+		auth.NewBearer("bhg", map[string]string{"0123456789abcdef": "api"}),
+		auth.NewHMAC(map[string][]byte{"svc": []byte("shared-secret")}),
+		sessions,
+	)
+
 	r := mux.NewRouter()
 	r.HandleFunc("/hello", hello).Methods("GET")
+	r.HandleFunc("/login", login(sessions)).Methods("POST")
+	r.HandleFunc("/logout", logout(sessions)).Methods("POST")
 	n := negroni.Classic()
-	n.Use(&badAuth{
-		Username: "admin",
-		Password: "password",
-	})
+	n.Use(authn)
 	n.UseHandler(r)
-	http.ListenAndServe(":8000", n)
+	log.Fatal(http.ListenAndServe(":8000", n))
 }
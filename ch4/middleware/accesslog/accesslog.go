@@ -0,0 +1,130 @@
+// Package accesslog provides access-log middleware for net/http and negroni. Every request gets an ID, and one
+// structured logrus entry is written per request with its status, size and latency.
+package accesslog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultHeader is the header the request ID is read from and echoed in.
+const DefaultHeader = "X-Request-Id"
+
+type contextKey struct{}
+
+// RequestID returns the ID the middleware assigned to the request of ctx.
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok
+}
+
+// Logger logs every request it handles. It implements negroni.Handler, Wrap adapts it to plain net/http handlers.
+type Logger struct {
+	Log logrus.FieldLogger
+	// Header carries the request ID in both directions.
+	Header string
+	// TrustHeader reuses IDs sent by clients or upstream proxies instead of always generating a new one.
+	TrustHeader bool
+}
+
+// New returns a Logger writing to log, or to a JSON formatted logrus.Logger on stderr if log is nil.
+func New(log logrus.FieldLogger) *Logger {
+	if log == nil {
+		l := logrus.New()
+		l.SetFormatter(&logrus.JSONFormatter{})
+		log = l
+	}
+	return &Logger{Log: log, Header: DefaultHeader}
+}
+
+func (l *Logger) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	start := time.Now()
+	id := l.requestID(r)
+	w.Header().Set(l.Header, id)
+	r = r.WithContext(context.WithValue(r.Context(), contextKey{}, id))
+
+	rw := NewResponseWriter(w)
+	// Logging from a defer still records requests whose handler panics. The panic is passed on to a recovery
+	// middleware further out, which usually answers with a 500.
+	defer func() {
+		p := recover()
+		l.log(r, rw, id, start, p)
+		if p != nil {
+			panic(p)
+		}
+	}()
+	next(rw, r)
+}
+
+func (l *Logger) log(r *http.Request, rw *ResponseWriter, id string, start time.Time, p interface{}) {
+	status := rw.Status()
+	if p != nil && rw.status == 0 {
+		status = http.StatusInternalServerError
+	}
+	fields := logrus.Fields{
+		"request_id": id,
+		"method":     r.Method,
+		"uri":        r.URL.RequestURI(),
+		"proto":      r.Proto,
+		"host":       r.Host,
+		"remote":     r.RemoteAddr,
+		"user_agent": r.UserAgent(),
+		"status":     status,
+		"bytes":      rw.Size(),
+		"latency_ms": float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if p != nil {
+		fields["panic"] = fmt.Sprint(p)
+	}
+	entry := l.Log.WithFields(fields)
+	switch {
+	case status >= 500:
+		entry.Error("request")
+	case status >= 400:
+		entry.Warn("request")
+	default:
+		entry.Info("request")
+	}
+}
+
+// Wrap returns a handler that logs the requests passed to h.
+func (l *Logger) Wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.ServeHTTP(w, r, h.ServeHTTP)
+	})
+}
+
+func (l *Logger) requestID(r *http.Request) string {
+	if l.TrustHeader {
+		if id := r.Header.Get(l.Header); validID(id) {
+			return id
+		}
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// IDs only correlate log lines, a timestamp is better than failing the request.
+		return time.Now().UTC().Format("20060102T150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// validID accepts short IDs made of characters that can't break log lines or headers.
+func validID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// entries returns a Logger writing JSON to a buffer and a function decoding the entries written so far.
+func entries(t *testing.T) (*Logger, func() []map[string]interface{}) {
+	var buf bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&buf)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	return New(log), func() []map[string]interface{} {
+		var list []map[string]interface{}
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var e map[string]interface{}
+			if err := dec.Decode(&e); err != nil {
+				t.Fatal(err)
+			}
+			list = append(list, e)
+		}
+		return list
+	}
+}
+
+func TestLogger(t *testing.T) {
+	l, logged := entries(t)
+	h := l.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pot?x=1", nil))
+
+	list := logged()
+	if len(list) != 1 {
+		t.Fatalf("%d entries, want 1", len(list))
+	}
+	e := list[0]
+	if e["status"] != float64(http.StatusTeapot) || e["bytes"] != float64(15) || e["uri"] != "/pot?x=1" ||
+		e["level"] != "warning" || e["request_id"] != w.Header().Get(DefaultHeader) {
+		t.Errorf("entry = %v", e)
+	}
+}
+
+func TestLoggerPanic(t *testing.T) {
+	l, logged := entries(t)
+	h := l.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("recovered %v, want the handler's panic", p)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	list := logged()
+	if len(list) != 1 {
+		t.Fatalf("%d entries, want 1", len(list))
+	}
+	if e := list[0]; e["status"] != float64(http.StatusInternalServerError) || e["panic"] != "boom" || e["level"] != "error" {
+		t.Errorf("entry = %v", e)
+	}
+}
@@ -0,0 +1,118 @@
+// Package auth provides authentication middleware for net/http and negroni: HTTP Basic, bearer tokens,
+// HMAC-signed requests and session cookies. Secrets are always compared in constant time.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrNoCredentials is returned by an Authenticator when the request doesn't carry its kind of credentials, so the
+// next one is tried.
+var ErrNoCredentials = errors.New("auth: no credentials")
+
+// ErrInvalidCredentials is returned when credentials were sent but are wrong.
+var ErrInvalidCredentials = errors.New("auth: invalid credentials")
+
+// Authenticator checks the credentials of a request and returns the authenticated user.
+type Authenticator interface {
+	Authenticate(r *http.Request) (string, error)
+}
+
+// Challenger is implemented by authenticators that announce themselves in the WWW-Authenticate header of 401
+// responses.
+type Challenger interface {
+	Challenge() string
+}
+
+// contextKey is unexported so no other package can read or overwrite the user by accident.
+type contextKey struct{}
+
+// WithUser returns a copy of ctx carrying the authenticated user.
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// User returns the user stored by the middleware, ok is false for unauthenticated requests.
+func User(ctx context.Context) (user string, ok bool) {
+	user, ok = ctx.Value(contextKey{}).(string)
+	return user, ok
+}
+
+// Middleware lets a request through if any of its authenticators accepts it and stores the user in the request
+// context. It implements negroni.Handler, Wrap adapts it to plain net/http handlers.
+type Middleware struct {
+	Authenticators []Authenticator
+	// Unauthorized writes the response for rejected requests, the default is a plain 401.
+	Unauthorized func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+func New(authenticators ...Authenticator) *Middleware {
+	return &Middleware{Authenticators: authenticators}
+}
+
+// Authenticate returns the user of the first authenticator that accepts r. Wrong credentials of one kind don't
+// prevent another authenticator from accepting the request.
+func (m *Middleware) Authenticate(r *http.Request) (string, error) {
+	err := ErrNoCredentials
+	for _, a := range m.Authenticators {
+		user, aerr := a.Authenticate(r)
+		if aerr == nil {
+			return user, nil
+		}
+		if !errors.Is(aerr, ErrNoCredentials) {
+			err = aerr
+		}
+	}
+	return "", err
+}
+
+func (m *Middleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	user, err := m.Authenticate(r)
+	if err != nil {
+		m.unauthorized(w, r, err)
+		return
+	}
+	next(w, r.WithContext(WithUser(r.Context(), user)))
+}
+
+// Wrap returns a handler that authenticates requests before passing them to h.
+func (m *Middleware) Wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.ServeHTTP(w, r, h.ServeHTTP)
+	})
+}
+
+func (m *Middleware) unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	for _, a := range m.Authenticators {
+		if c, ok := a.(Challenger); ok {
+			w.Header().Add("WWW-Authenticate", c.Challenge())
+		}
+	}
+	if m.Unauthorized != nil {
+		m.Unauthorized(w, r, err)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// equal compares two secrets in constant time. Both are hashed first, so the comparison doesn't leak their lengths
+// either.
+func equal(a, b string) bool {
+	ha := sha256.Sum256([]byte(a))
+	hb := sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
+
+// credentials returns the value of an "Authorization: <scheme> <value>" header.
+func credentials(r *http.Request, scheme string) (string, bool) {
+	h := r.Header.Get("Authorization")
+	if len(h) <= len(scheme) || !strings.EqualFold(h[:len(scheme)], scheme) || h[len(scheme)] != ' ' {
+		return "", false
+	}
+	return strings.TrimSpace(h[len(scheme)+1:]), true
+}
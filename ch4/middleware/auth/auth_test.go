@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serve sends r through a middleware with the given authenticators and returns the status and the user the handler
+// saw.
+func serve(r *http.Request, authenticators ...Authenticator) (int, string) {
+	var user string
+	h := New(authenticators...).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ = User(r.Context())
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code, user
+}
+
+func TestBasicAndBearer(t *testing.T) {
+	basic := NewBasic("test", map[string]string{"admin": "password"})
+	bearer := NewBearer("test", map[string]string{"0123456789abcdef": "api", "": "nobody"})
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+		user   string
+	}{
+		{"basic", "Basic YWRtaW46cGFzc3dvcmQ=", http.StatusOK, "admin"},
+		{"basic wrong password", "Basic YWRtaW46d3Jvbmc=", http.StatusUnauthorized, ""},
+		{"basic unknown user", "Basic bm9ib2R5OnBhc3N3b3Jk", http.StatusUnauthorized, ""},
+		{"bearer", "Bearer 0123456789abcdef", http.StatusOK, "api"},
+		{"bearer lower case scheme", "bearer 0123456789abcdef", http.StatusOK, "api"},
+		{"bearer wrong token", "Bearer fedcba9876543210", http.StatusUnauthorized, ""},
+		{"bearer empty token", "Bearer ", http.StatusUnauthorized, ""},
+		{"bearer blank token", "Bearer    ", http.StatusUnauthorized, ""},
+		{"no credentials", "", http.StatusUnauthorized, ""},
+		{"other scheme", "Digest username=admin", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			status, user := serve(r, basic, bearer)
+			if status != tt.status || user != tt.user {
+				t.Errorf("got %d %q, want %d %q", status, user, tt.status, tt.user)
+			}
+		})
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	w := httptest.NewRecorder()
+	New(NewBasic("bhg", nil), NewBearer("bhg", nil)).Wrap(http.NotFoundHandler()).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	got := w.Header().Values("WWW-Authenticate")
+	if len(got) != 2 || got[0] != `Basic realm="bhg"` || got[1] != `Bearer realm="bhg"` {
+		t.Errorf("WWW-Authenticate = %q", got)
+	}
+}
+
+func signed(t *testing.T, keyID string, key []byte, body string) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/api/items?page=2", strings.NewReader(body))
+	if err := Sign(r, keyID, key); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestHMAC(t *testing.T) {
+	h := NewHMAC(map[string][]byte{"svc": []byte("shared-secret")})
+
+	r := signed(t, "svc", []byte("shared-secret"), `{"name":"x"}`)
+	if user, err := h.Authenticate(r); err != nil || user != "svc" {
+		t.Fatalf("Authenticate() = %q, %v", user, err)
+	}
+	if b, _ := readBody(r, -1); string(b) != `{"name":"x"}` {
+		t.Errorf("body after Authenticate = %q", b)
+	}
+
+	tampered := signed(t, "svc", []byte("shared-secret"), `{"name":"x"}`)
+	tampered.URL.RawQuery = "page=3"
+	if _, err := h.Authenticate(tampered); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("tampered URI: got %v", err)
+	}
+
+	unknown := signed(t, "other", []byte("shared-secret"), "")
+	if _, err := h.Authenticate(unknown); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown key id: got %v", err)
+	}
+
+	wrongKey := signed(t, "svc", []byte("guess"), "")
+	if _, err := h.Authenticate(wrongKey); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong key: got %v", err)
+	}
+}
+
+func TestHMACStale(t *testing.T) {
+	h := NewHMAC(map[string][]byte{"svc": []byte("shared-secret")})
+	for _, skew := range []time.Duration{-10 * time.Minute, 10 * time.Minute} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		ts := strconv.FormatInt(time.Now().Add(skew).Unix(), 10)
+		sig := signature([]byte("shared-secret"), r.Method, r.URL.RequestURI(), ts, nil)
+		r.Header.Set(TimestampHeader, ts)
+		r.Header.Set("Authorization", HMACScheme+" svc:"+hex.EncodeToString(sig))
+		if _, err := h.Authenticate(r); !errors.Is(err, ErrStale) {
+			t.Errorf("skew %s: got %v, want ErrStale", skew, err)
+		}
+	}
+}
+
+// login starts a session and returns a request carrying its cookie.
+func login(t *testing.T, s *Sessions, user string) *http.Request {
+	t.Helper()
+	w := httptest.NewRecorder()
+	if err := s.Login(w, user); err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range w.Result().Cookies() {
+		r.AddCookie(c)
+	}
+	return r
+}
+
+func TestSessions(t *testing.T) {
+	s := NewSessions(time.Hour)
+	r := login(t, s, "alice")
+	if user, err := s.Authenticate(r); err != nil || user != "alice" {
+		t.Fatalf("Authenticate() = %q, %v", user, err)
+	}
+
+	w := httptest.NewRecorder()
+	s.Logout(w, r)
+	if _, err := s.Authenticate(r); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("after Logout: got %v", err)
+	}
+	if c := w.Result().Cookies(); len(c) != 1 || c[0].MaxAge >= 0 {
+		t.Errorf("Logout didn't clear the cookie: %v", c)
+	}
+
+	if _, err := s.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil)); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("without cookie: got %v", err)
+	}
+}
+
+func TestSessionsExpiry(t *testing.T) {
+	s := NewSessions(time.Millisecond)
+	r := login(t, s, "alice")
+	time.Sleep(5 * time.Millisecond)
+	if _, err := s.Authenticate(r); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expired session: got %v", err)
+	}
+}
+
+func TestSessionsZeroValue(t *testing.T) {
+	s := &Sessions{TTL: time.Hour}
+	r := login(t, s, "alice")
+	if user, err := s.Authenticate(r); err != nil || user != "alice" {
+		t.Errorf("Authenticate() = %q, %v", user, err)
+	}
+}
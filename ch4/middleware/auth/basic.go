@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Basic checks HTTP Basic credentials against a fixed set of users.
+type Basic struct {
+	Realm string
+	// Users maps user names to passwords.
+	Users map[string]string
+}
+
+func NewBasic(realm string, users map[string]string) *Basic {
+	return &Basic{Realm: realm, Users: users}
+}
+
+func (b *Basic) Authenticate(r *http.Request) (string, error) {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return "", ErrNoCredentials
+	}
+	want, known := b.Users[user]
+	// Unknown users are compared too, so they take as long as wrong passwords.
+	if !equal(pass, want) || !known {
+		return "", ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+func (b *Basic) Challenge() string {
+	return fmt.Sprintf("Basic realm=%q", b.Realm)
+}
+
+// Bearer checks "Authorization: Bearer <token>" headers against a fixed set of API tokens.
+type Bearer struct {
+	Realm string
+	// Tokens maps tokens to the users they belong to.
+	Tokens map[string]string
+}
+
+func NewBearer(realm string, tokens map[string]string) *Bearer {
+	return &Bearer{Realm: realm, Tokens: tokens}
+}
+
+func (b *Bearer) Authenticate(r *http.Request) (string, error) {
+	token, ok := credentials(r, "Bearer")
+	if !ok {
+		return "", ErrNoCredentials
+	}
+	// An empty token never matches, even if Tokens has an empty key by mistake.
+	if token == "" {
+		return "", ErrInvalidCredentials
+	}
+	// Every token is compared, so the time taken doesn't depend on which one matched, if any.
+	var user string
+	for t, u := range b.Tokens {
+		if t != "" && equal(token, t) {
+			user = u
+		}
+	}
+	if user == "" {
+		return "", ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+func (b *Bearer) Challenge() string {
+	return fmt.Sprintf("Bearer realm=%q", b.Realm)
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultCookieName is the name of the session cookie set by Sessions.
+const DefaultCookieName = "session"
+
+// Sessions is an in-memory session store that authenticates requests by cookie. Session ids are 256 bit random
+// values; the store only keeps their SHA-256, so looking one up doesn't leak timing information about valid ids.
+// A zero Sessions works once TTL is set, NewSessions also sets the other defaults.
+type Sessions struct {
+	CookieName string
+	TTL        time.Duration
+	// Secure marks the cookie as HTTPS only, leave it on unless the server is plain HTTP.
+	Secure bool
+
+	mu       sync.Mutex
+	sessions map[[sha256.Size]byte]session
+}
+
+type session struct {
+	user    string
+	expires time.Time
+}
+
+func NewSessions(ttl time.Duration) *Sessions {
+	return &Sessions{
+		CookieName: DefaultCookieName,
+		TTL:        ttl,
+		Secure:     true,
+		sessions:   make(map[[sha256.Size]byte]session),
+	}
+}
+
+// Login starts a session for user and sets its cookie on w.
+func (s *Sessions) Login(w http.ResponseWriter, user string) error {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	id := base64.RawURLEncoding.EncodeToString(raw)
+	expires := time.Now().Add(s.TTL)
+
+	s.mu.Lock()
+	if s.sessions == nil {
+		s.sessions = make(map[[sha256.Size]byte]session)
+	}
+	s.expire()
+	s.sessions[sha256.Sum256([]byte(id))] = session{user: user, expires: expires}
+	s.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.cookieName(),
+		Value:    id,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   s.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// Logout ends the session of r, if any, and clears its cookie.
+func (s *Sessions) Logout(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(s.cookieName()); err == nil {
+		s.mu.Lock()
+		delete(s.sessions, sha256.Sum256([]byte(c.Value)))
+		s.mu.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.cookieName(),
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   s.Secure,
+	})
+}
+
+func (s *Sessions) Authenticate(r *http.Request) (string, error) {
+	c, err := r.Cookie(s.cookieName())
+	if err != nil {
+		return "", ErrNoCredentials
+	}
+	key := sha256.Sum256([]byte(c.Value))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[key]
+	if !ok {
+		return "", ErrInvalidCredentials
+	}
+	if time.Now().After(sess.expires) {
+		delete(s.sessions, key)
+		return "", ErrInvalidCredentials
+	}
+	return sess.user, nil
+}
+
+func (s *Sessions) cookieName() string {
+	if s.CookieName == "" {
+		return DefaultCookieName
+	}
+	return s.CookieName
+}
+
+// expire drops expired sessions. The caller holds s.mu.
+func (s *Sessions) expire() {
+	now := time.Now()
+	for k, sess := range s.sessions {
+		if now.After(sess.expires) {
+			delete(s.sessions, k)
+		}
+	}
+}
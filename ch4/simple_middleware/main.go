@@ -2,26 +2,20 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"net/http"
-)
-
-type logger struct {
-	Inner http.Handler
-}
 
-func (l *logger) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	log.Println("start")
-	l.Inner.ServeHTTP(w, r)
-	log.Println("finish")
-}
+	"github.com/bilalcaliskan/blackhat-go/ch4/middleware/accesslog"
+	log "github.com/sirupsen/logrus"
+)
 
 func hello(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprint(w, "Hello\n")
+	id, _ := accesslog.RequestID(r.Context())
+	fmt.Fprintf(w, "Hello, your request ID is %s\n", id)
 }
 
 func main() {
+	log.SetFormatter(&log.JSONFormatter{})
 	f := http.HandlerFunc(hello)
-	l := logger{Inner: f}
-	http.ListenAndServe(":8000", &l)
+	l := accesslog.New(log.StandardLogger())
+	log.Fatal(http.ListenAndServe(":8000", l.Wrap(f)))
 }
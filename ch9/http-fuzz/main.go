@@ -2,14 +2,35 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
+	"github.com/bilalcaliskan/blackhat-go/internal/httpclient"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/http/cookiejar"
 	"regexp"
 )
 
 func main() {
+	httpFlags := (&httpclient.Config{}).RegisterFlags(flag.CommandLine)
+	flag.Parse()
+	client, err := httpFlags.Client()
+	if err != nil {
+		log.Fatalf("[!] Unable to create client: %s\n", err)
+	}
+
+	// log.Fatalf skips deferred calls, so the HAR file and request log are closed before reporting a failed run.
+	err = run(client)
+	if cerr := httpclient.Close(client); cerr != nil {
+		log.Printf("[!] Unable to write HAR file: %s\n", cerr)
+	}
+	if err != nil {
+		log.Fatalf("[!] %s\n", err)
+	}
+}
+
+func run(client *http.Client) error {
 	payloads := []string{
 		"baseline",
 		")",
@@ -32,7 +53,13 @@ func main() {
 	}
 
 	for _, payload := range payloads {
-		client := new(http.Client)
+		// Each payload starts a fresh session, so cookies set by one response can't change the next result.
+		c := *client
+		var err error
+		if c.Jar, err = cookiejar.New(nil); err != nil {
+			return fmt.Errorf("Unable to create cookie jar: %w", err)
+		}
+
 		body := []byte(fmt.Sprintf("username=%s&password=p", payload))
 		req, err := http.NewRequest(
 			"POST",
@@ -40,18 +67,18 @@ func main() {
 			bytes.NewReader(body),
 		)
 		if err != nil {
-			log.Fatalf("[!] Unable to generate request: %s\n", err)
+			return fmt.Errorf("Unable to generate request: %w", err)
 		}
 		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-		resp, err := client.Do(req)
+		resp, err := c.Do(req)
 		if err != nil {
-			log.Fatalf("[!] Unable to process response: %s\n", err)
+			return fmt.Errorf("Unable to process response: %w", err)
 		}
 		body, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
 		if err != nil {
-			log.Fatalf("[!] Unable to read response body: %s\n", err)
+			return fmt.Errorf("Unable to read response body: %w", err)
 		}
-		resp.Body.Close()
 
 		for idx, re := range errRegexes {
 			if re.MatchString(string(body)) {
@@ -64,4 +91,5 @@ func main() {
 			}
 		}
 	}
-}
\ No newline at end of file
+	return nil
+}
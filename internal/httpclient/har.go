@@ -0,0 +1,575 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultHARMaxBody is the number of body bytes recorded per request and response when Config.HARMaxBody is zero.
+const DefaultHARMaxBody = 1 << 20
+
+// DefaultHARFlushEvery is the number of completed entries after which a Recorder rewrites its file.
+const DefaultHARFlushEvery = 100
+
+// HAR is an HTTP Archive 1.2 document, see http://www.softwareishard.com/blog/har-12-spec/.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	// Time is the total duration of the request in milliseconds.
+	Time     float64     `json:"time"`
+	Request  HARRequest  `json:"request"`
+	Response HARResponse `json:"response"`
+	Cache    struct{}    `json:"cache"`
+	Timings  HARTimings  `json:"timings"`
+	Comment  string      `json:"comment,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	Comment     string         `json:"comment,omitempty"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+// HARPostData holds a request body. Bodies that aren't valid UTF-8 are stored base64 encoded with Encoding set,
+// an extension also used by browsers for response content.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARTimings are in milliseconds, -1 marks phases that didn't happen, e.g. DNS and connect for reused connections.
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// ReadHAR decodes a HAR document.
+func ReadHAR(r io.Reader) (*HAR, error) {
+	var h HAR
+	if err := json.NewDecoder(r).Decode(&h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// ReadHARFile decodes the HAR document stored in path.
+func ReadHARFile(path string) (*HAR, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadHAR(f)
+}
+
+// Recorder is an http.RoundTripper that records every request and response passing through it. An entry is
+// complete once the response body has been read to the end or closed.
+type Recorder struct {
+	next http.RoundTripper
+	// MaxBody is the number of body bytes recorded per request and response, longer bodies are truncated and marked
+	// with a comment. Zero or less records no bodies at all.
+	MaxBody int64
+	// Path is rewritten with the whole archive after every FlushEvery completed entries and by Flush and Close, so
+	// the file stays mostly complete even if the program exits without cleaning up. Empty keeps the entries in
+	// memory only.
+	Path       string
+	FlushEvery int
+	// Redact replaces API keys and credentials as in the request log, see RedactParams and RedactHeaders. Archives
+	// recorded with it can't replay authenticated requests.
+	Redact bool
+
+	mu      sync.Mutex
+	har     HAR
+	pending int
+	err     error
+}
+
+// NewRecorder records the requests sent through next, or http.DefaultTransport if next is nil, redacting secrets.
+func NewRecorder(next http.RoundTripper, path string, maxBody int64) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{
+		next:       next,
+		MaxBody:    maxBody,
+		Path:       path,
+		FlushEvery: DefaultHARFlushEvery,
+		Redact:     true,
+		har: HAR{Log: HARLog{
+			Version: "1.2",
+			Creator: HARCreator{Name: "blackhat-go", Version: "1.0"},
+			Entries: []HAREntry{},
+		}},
+	}
+}
+
+// HAR returns a copy of the archive recorded so far.
+func (r *Recorder) HAR() HAR {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h := r.har
+	h.Log.Entries = append([]HAREntry(nil), r.har.Log.Entries...)
+	return h
+}
+
+// WriteTo writes the archive recorded so far as JSON.
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	h := r.HAR()
+	b, err := marshalHAR(&h)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// marshalHAR encodes h without escaping &, < and >, which are common in URLs and bodies.
+func marshalHAR(h *HAR) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(h); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Err returns the first error writing Path. Entries completed by reading a response body can't report it to the
+// caller directly.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) add(e HAREntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.har.Log.Entries = append(r.har.Log.Entries, e)
+	r.pending++
+	if r.Path == "" || r.pending < r.FlushEvery {
+		return nil
+	}
+	return r.save()
+}
+
+// Flush writes Path if entries were completed since it was last written.
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.Path == "" || r.pending == 0 {
+		return r.err
+	}
+	return r.save()
+}
+
+// Close writes the entries still pending to Path. Entries completed after Close are kept until the next Flush.
+func (r *Recorder) Close() error {
+	return r.Flush()
+}
+
+// save replaces Path atomically and records the first error for Err. Entries are added concurrently, so the caller
+// holds the lock while the file is written to keep an older snapshot from replacing a newer one.
+func (r *Recorder) save() error {
+	err := r.write()
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+	if err == nil {
+		r.pending = 0
+	}
+	return err
+}
+
+func (r *Recorder) write() error {
+	b, err := marshalHAR(&r.har)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(r.Path), ".har-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.Path)
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	t := &harTiming{start: time.Now()}
+	req = req.Clone(httptrace.WithClientTrace(req.Context(), t.trace()))
+
+	// The transport may still be sending the request body when RoundTrip returns, so the post data is finalised by
+	// the body itself and only picked up once the entry is complete.
+	var reqBody *capture
+	if req.Body != nil && req.Body != http.NoBody {
+		mimeType := req.Header.Get("Content-Type")
+		reqBody = &capture{
+			ReadCloser: req.Body,
+			max:        r.MaxBody,
+			done: func(c *capture) {
+				c.post = c.postData(mimeType)
+			},
+		}
+		req.Body = reqBody
+	}
+	postData := func(entry *HAREntry) {
+		if reqBody != nil {
+			entry.Request.PostData, entry.Request.BodySize = reqBody.request(req.Header.Get("Content-Type"))
+		}
+	}
+
+	entry := HAREntry{StartedDateTime: t.start, Request: harRequest(req, r.Redact)}
+	res, err := r.next.RoundTrip(req)
+	if err != nil {
+		postData(&entry)
+		entry.Comment = err.Error()
+		entry.Time, entry.Timings = t.finish(time.Now())
+		if serr := r.add(entry); serr != nil {
+			return nil, fmt.Errorf("httpclient: recording %s: %w", req.URL, serr)
+		}
+		return nil, err
+	}
+
+	entry.Response = harResponse(res, r.Redact)
+	res.Body = &capture{
+		ReadCloser: res.Body,
+		max:        r.MaxBody,
+		done: func(c *capture) {
+			postData(&entry)
+			entry.Response.Content = c.content(res.Header.Get("Content-Type"))
+			entry.Response.BodySize = c.n
+			entry.Time, entry.Timings = t.finish(time.Now())
+			r.add(entry)
+		},
+	}
+	return res, nil
+}
+
+func harRequest(req *http.Request, redact bool) HARRequest {
+	u := req.URL
+	header := req.Header
+	if redact {
+		u = redactURL(u)
+		header = redactHeader(header)
+	}
+	h := HARRequest{
+		Method:      req.Method,
+		URL:         u.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     []HARCookie{},
+		Headers:     harHeaders(header),
+		QueryString: []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+	if h.HTTPVersion == "" {
+		h.HTTPVersion = "HTTP/1.1"
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		h.Headers = append(h.Headers, HARNameValue{"Host", req.Host})
+	}
+	for _, c := range req.Cookies() {
+		h.Cookies = append(h.Cookies, HARCookie{Name: c.Name, Value: redactCookie(c.Value, redact)})
+	}
+	for name, values := range u.Query() {
+		for _, v := range values {
+			h.QueryString = append(h.QueryString, HARNameValue{name, v})
+		}
+	}
+	return h
+}
+
+func harResponse(res *http.Response, redact bool) HARResponse {
+	header := res.Header
+	if redact {
+		header = redactHeader(header)
+	}
+	h := HARResponse{
+		Status:      res.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(res.Status, fmt.Sprint(res.StatusCode))),
+		HTTPVersion: res.Proto,
+		Cookies:     []HARCookie{},
+		Headers:     harHeaders(header),
+		RedirectURL: res.Header.Get("Location"),
+		HeadersSize: -1,
+	}
+	for _, c := range res.Cookies() {
+		h.Cookies = append(h.Cookies, HARCookie{
+			Name:     c.Name,
+			Value:    redactCookie(c.Value, redact),
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		})
+	}
+	return h
+}
+
+func redactCookie(value string, redact bool) string {
+	if redact {
+		return Redacted
+	}
+	return value
+}
+
+func harHeaders(header http.Header) []HARNameValue {
+	headers := []HARNameValue{}
+	for name, values := range header {
+		for _, v := range values {
+			headers = append(headers, HARNameValue{name, v})
+		}
+	}
+	return headers
+}
+
+// capture records up to max bytes of a body while it is read and calls done once, on EOF or Close. Request bodies
+// are read by the transport on a goroutine of its own, so the recorded state is guarded by mu, which done holds too.
+type capture struct {
+	io.ReadCloser
+	max  int64
+	once sync.Once
+	done func(*capture)
+
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	n    int64
+	eof  bool
+	post *HARPostData
+}
+
+func (c *capture) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.mu.Lock()
+	if n > 0 {
+		if room := c.max - int64(c.buf.Len()); room > 0 {
+			if int64(n) < room {
+				room = int64(n)
+			}
+			c.buf.Write(p[:room])
+		}
+		c.n += int64(n)
+	}
+	if err == io.EOF {
+		c.eof = true
+	}
+	c.mu.Unlock()
+	if err == io.EOF {
+		c.finish()
+	}
+	return n, err
+}
+
+func (c *capture) Close() error {
+	err := c.ReadCloser.Close()
+	c.finish()
+	return err
+}
+
+func (c *capture) finish() {
+	if c.done != nil {
+		c.once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.done(c)
+		})
+	}
+}
+
+// comment explains why the recorded body is incomplete, if it is.
+func (c *capture) comment() string {
+	switch {
+	case c.n > int64(c.buf.Len()):
+		return fmt.Sprintf("body truncated to %d of %d bytes", c.buf.Len(), c.n)
+	case !c.eof:
+		return fmt.Sprintf("body closed after %d bytes", c.n)
+	}
+	return ""
+}
+
+// text returns the recorded body, base64 encoded if it isn't valid UTF-8.
+func (c *capture) text() (string, string) {
+	b := c.buf.Bytes()
+	if utf8.Valid(b) {
+		return string(b), ""
+	}
+	return base64.StdEncoding.EncodeToString(b), "base64"
+}
+
+func (c *capture) postData(mimeType string) *HARPostData {
+	// The transport stops reading request bodies at Content-Length without waiting for EOF.
+	c.eof = true
+	text, encoding := c.text()
+	return &HARPostData{MimeType: mimeType, Text: text, Encoding: encoding, Comment: c.comment()}
+}
+
+// request returns the post data finalised on EOF or Close and the size of the request body. A body the transport
+// is still sending is recorded as far as it got.
+func (c *capture) request(mimeType string) (*HARPostData, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.post != nil {
+		return c.post, c.n
+	}
+	text, encoding := c.text()
+	comment := fmt.Sprintf("body still being sent after %d bytes", c.n)
+	return &HARPostData{MimeType: mimeType, Text: text, Encoding: encoding, Comment: comment}, c.n
+}
+
+func (c *capture) content(mimeType string) HARContent {
+	text, encoding := c.text()
+	return HARContent{Size: c.n, MimeType: mimeType, Text: text, Encoding: encoding, Comment: c.comment()}
+}
+
+// harTiming collects the phases of a request through httptrace.
+type harTiming struct {
+	mu                               sync.Mutex
+	start                            time.Time
+	dnsStart, dnsDone                time.Time
+	connectStart, connectDone        time.Time
+	tlsStart, tlsDone                time.Time
+	gotConn, wroteRequest, firstByte time.Time
+}
+
+func (t *harTiming) set(field *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if field.IsZero() {
+		*field = time.Now()
+	}
+}
+
+func (t *harTiming) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.set(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.set(&t.dnsDone) },
+		ConnectStart:         func(string, string) { t.set(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { t.set(&t.connectDone) },
+		TLSHandshakeStart:    func() { t.set(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.set(&t.tlsDone) },
+		GotConn:              func(httptrace.GotConnInfo) { t.set(&t.gotConn) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.set(&t.wroteRequest) },
+		GotFirstResponseByte: func() { t.set(&t.firstByte) },
+	}
+}
+
+// finish returns the total time and the phases of the request in milliseconds.
+func (t *harTiming) finish(end time.Time) (float64, HARTimings) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ms := func(from, to time.Time) float64 {
+		if from.IsZero() || to.IsZero() {
+			return -1
+		}
+		return float64(to.Sub(from)) / float64(time.Millisecond)
+	}
+
+	timings := HARTimings{
+		DNS:     ms(t.dnsStart, t.dnsDone),
+		Connect: ms(t.connectStart, t.connectDone),
+		SSL:     ms(t.tlsStart, t.tlsDone),
+		Send:    ms(t.gotConn, t.wroteRequest),
+		Wait:    ms(t.wroteRequest, t.firstByte),
+		Receive: ms(t.firstByte, end),
+	}
+	// Blocked is the time spent waiting for a connection, minus dialing it.
+	timings.Blocked = ms(t.start, t.gotConn)
+	for _, d := range []float64{timings.DNS, timings.Connect, timings.SSL} {
+		if d > 0 && timings.Blocked > 0 {
+			timings.Blocked -= d
+		}
+	}
+	// HAR includes the TLS handshake in connect.
+	if timings.SSL > 0 && timings.Connect >= 0 {
+		timings.Connect += timings.SSL
+	}
+	for _, d := range []*float64{&timings.Send, &timings.Wait, &timings.Receive} {
+		if *d < 0 {
+			*d = 0
+		}
+	}
+	return ms(t.start, end), timings
+}
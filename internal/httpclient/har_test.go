@@ -0,0 +1,156 @@
+package httpclient
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, client *http.Client, url string) {
+	t.Helper()
+	res, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(res.Body)
+	res.Body.Close()
+}
+
+func TestRecorderFlushesInBatches(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "out.har")
+	rec := NewRecorder(nil, path, DefaultHARMaxBody)
+	rec.FlushEvery = 2
+	client := &http.Client{Transport: rec}
+
+	get(t, client, srv.URL+"/1")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("file written before a batch was complete: %v", err)
+	}
+	get(t, client, srv.URL+"/2")
+	get(t, client, srv.URL+"/3")
+	if h, err := ReadHARFile(path); err != nil || len(h.Log.Entries) != 2 {
+		t.Fatalf("after a batch: %v entries, %v", h, err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	h, err := ReadHARFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Log.Entries) != 3 {
+		t.Fatalf("after Close: %d entries, want 3", len(h.Log.Entries))
+	}
+}
+
+func TestRecorderRedacts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "server-secret"})
+	}))
+	defer srv.Close()
+
+	for _, secrets := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "out.har")
+		client, err := New(Config{HARFile: path, HARSecrets: secrets})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, _ := http.NewRequest("GET", srv.URL+"/shodan/host/1.2.3.4?key=url-secret", nil)
+		req.Header.Set("Authorization", "Bearer header-secret")
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if err := Close(client); err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{"url-secret", "header-secret", "server-secret"} {
+			if strings.Contains(string(b), secret) != secrets {
+				t.Errorf("secrets=%v: %s recorded: %v", secrets, secret, !secrets)
+			}
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRecorderPostDataSentLate(t *testing.T) {
+	// Like net/http, the transport returns the response while it is still writing the request body on another
+	// goroutine. The response body only ends once the request body was sent.
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		pr, pw := io.Pipe()
+		go func() {
+			ioutil.ReadAll(req.Body)
+			req.Body.Close()
+			pw.Close()
+		}()
+		return &http.Response{StatusCode: 200, Status: "200 OK", Proto: "HTTP/1.1", Header: http.Header{}, Body: pr}, nil
+	})
+	rec := NewRecorder(next, "", DefaultHARMaxBody)
+	client := &http.Client{Transport: rec}
+
+	res, err := client.Post("http://example.com/login", "application/x-www-form-urlencoded", strings.NewReader("user=admin&pass=admin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	h := rec.HAR()
+	if len(h.Log.Entries) != 1 {
+		t.Fatalf("%d entries, want 1", len(h.Log.Entries))
+	}
+	post := h.Log.Entries[0].Request.PostData
+	if post == nil || post.Text != "user=admin&pass=admin" || post.Comment != "" {
+		t.Errorf("PostData = %+v, want the full body", post)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	body := "first"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" && body != "first" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	rec := NewRecorder(nil, "", DefaultHARMaxBody)
+	client := &http.Client{Transport: rec}
+	get(t, client, srv.URL+"/same")
+	get(t, client, srv.URL+"/gone")
+	h := rec.HAR()
+
+	body = "second"
+	results := Replay(nil, &h)
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if r := results[0]; !r.BodyChanged || r.Got != http.StatusOK {
+		t.Errorf("changed body not detected: %+v", r)
+	}
+	if r := results[1]; r.Got != http.StatusNotFound || r.Want != http.StatusOK || !r.Changed() {
+		t.Errorf("changed status not detected: %+v", r)
+	}
+}
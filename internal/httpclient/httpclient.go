@@ -0,0 +1,200 @@
+// Package httpclient builds the http.Client shared by the tools in this repository: an upstream proxy such as
+// Burp, a cookie jar, optional TLS verification, User-Agent rotation, retries with backoff, request logging and
+// recording to HAR files.
+package httpclient
+
+import (
+	"bufio"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config describes the client built by New. The zero value behaves like http.DefaultClient plus a cookie jar.
+type Config struct {
+	// Proxy is the upstream proxy URL, e.g. http://127.0.0.1:8080 for Burp. When empty, the HTTP_PROXY,
+	// HTTPS_PROXY and NO_PROXY environment variables are used.
+	Proxy              string
+	InsecureSkipVerify bool
+	// UserAgents are used in turn for every request and replace any User-Agent set by the caller.
+	UserAgents []string
+	// Timeout bounds a whole request including reading the body, zero means no timeout.
+	Timeout time.Duration
+	// Retries is the number of additional attempts after network errors, 429 and 5xx responses. The delay starts
+	// at Backoff and doubles after every attempt, unless the server sends Retry-After.
+	Retries int
+	Backoff time.Duration
+	// Logger receives one line per request and response, nil disables logging. With Dump set, the full requests
+	// and responses including bodies are logged. API keys and credentials are redacted, see RedactParams and
+	// RedactHeaders.
+	Logger *log.Logger
+	Dump   bool
+	// HARFile records every request and response to this file in HAR 1.2 format, see Recorder. HARMaxBody limits
+	// the recorded body bytes per message, zero means DefaultHARMaxBody and a negative value records no bodies.
+	// The file is written in batches, call Close once the client is no longer used. API keys and credentials are
+	// redacted unless HARSecrets is set, which is needed to replay authenticated requests.
+	HARFile    string
+	HARMaxBody int64
+	HARSecrets bool
+}
+
+// New returns a client configured by c.
+func New(c Config) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.Proxy != "" {
+		proxy, err := url.Parse(c.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("httpclient: invalid proxy: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if c.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	var rt http.RoundTripper = transport
+	// The recorder sits closest to the network so every retry is recorded with the final headers.
+	if c.HARFile != "" {
+		max := c.HARMaxBody
+		if max == 0 {
+			max = DefaultHARMaxBody
+		}
+		recorder := NewRecorder(rt, c.HARFile, max)
+		recorder.Redact = !c.HARSecrets
+		rt = recorder
+	}
+	if c.Logger != nil {
+		rt = &logTransport{next: rt, logger: c.Logger, dump: c.Dump}
+	}
+	if c.Retries > 0 {
+		backoff := c.Backoff
+		if backoff <= 0 {
+			backoff = time.Second
+		}
+		rt = &retryTransport{next: rt, retries: c.Retries, backoff: backoff}
+	}
+	if len(c.UserAgents) > 0 {
+		rt = &userAgentTransport{next: rt, agents: c.UserAgents}
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: rt, Jar: jar, Timeout: c.Timeout}, nil
+}
+
+// Close writes the HAR entries a client returned by New still holds. It does nothing for other clients.
+func Close(client *http.Client) error {
+	rt := client.Transport
+	for {
+		switch t := rt.(type) {
+		case *userAgentTransport:
+			rt = t.next
+		case *retryTransport:
+			rt = t.next
+		case *logTransport:
+			rt = t.next
+		case *Recorder:
+			return t.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// RegisterFlags defines -proxy, -insecure, -user-agent, -user-agent-file, -http-timeout, -http-retries, -http-log,
+// -http-dump, -har, -har-max-body and -har-secrets on fs, using the current values of c as defaults. Call Load or
+// Client after parsing the flags. -http-retries defaults to c.Retries, so tools that retry on their own don't retry
+// twice unless asked to.
+func (c *Config) RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{config: c}
+	fs.StringVar(&c.Proxy, "proxy", c.Proxy, "upstream proxy URL, e.g. http://127.0.0.1:8080")
+	fs.BoolVar(&c.InsecureSkipVerify, "insecure", c.InsecureSkipVerify, "skip TLS certificate verification")
+	fs.Var((*stringList)(&c.UserAgents), "user-agent", "User-Agent to send, repeat to rotate between several")
+	fs.StringVar(&f.agentFile, "user-agent-file", "", "file with one User-Agent per line to rotate between")
+	fs.DurationVar(&c.Timeout, "http-timeout", c.Timeout, "timeout of a whole HTTP request, 0 for none")
+	fs.IntVar(&c.Retries, "http-retries", c.Retries, "retries after network errors, 429 and 5xx responses")
+	fs.StringVar(&f.log, "http-log", "", "log requests to this file, - for stderr")
+	fs.BoolVar(&c.Dump, "http-dump", c.Dump, "log full requests and responses including bodies")
+	fs.StringVar(&c.HARFile, "har", c.HARFile, "record requests and responses to this HAR file")
+	fs.Int64Var(&c.HARMaxBody, "har-max-body", c.HARMaxBody, "body bytes recorded per message, 0 for 1 MiB, -1 for none")
+	fs.BoolVar(&c.HARSecrets, "har-secrets", c.HARSecrets, "keep API keys, credentials and cookies in the HAR file")
+	return f
+}
+
+// Flags holds the flag values that need more work than setting a Config field.
+type Flags struct {
+	config    *Config
+	agentFile string
+	log       string
+}
+
+// Load reads the User-Agent file and opens the log file named by the flags.
+func (f *Flags) Load() error {
+	if f.agentFile != "" {
+		agents, err := readLines(f.agentFile)
+		if err != nil {
+			return err
+		}
+		f.config.UserAgents = append(f.config.UserAgents, agents...)
+	}
+	switch f.log {
+	case "":
+	case "-":
+		f.config.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	default:
+		w, err := os.OpenFile(f.log, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		f.config.Logger = log.New(w, "", log.LstdFlags)
+	}
+	return nil
+}
+
+// Client loads the flags and returns the configured client.
+func (f *Flags) Client() (*http.Client, error) {
+	if err := f.Load(); err != nil {
+		return nil, err
+	}
+	return New(*f.config)
+}
+
+type stringList []string
+
+func (s *stringList) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,67 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces the values of secrets in logs, dumps and HAR files.
+const Redacted = "REDACTED"
+
+// RedactParams are the query parameters holding API keys, such as Shodan's key. Names are case-sensitive.
+var RedactParams = []string{"key", "api_key", "apikey", "access_token", "token"}
+
+// RedactHeaders are the headers holding credentials or session cookies.
+var RedactHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"Ocp-Apim-Subscription-Key",
+}
+
+// redactURL returns a copy of u with the values of RedactParams replaced.
+func redactURL(u *url.URL) *url.URL {
+	c := *u
+	if c.RawQuery == "" {
+		return &c
+	}
+	q := c.Query()
+	changed := false
+	for _, name := range RedactParams {
+		if values, ok := q[name]; ok {
+			for i := range values {
+				values[i] = Redacted
+			}
+			changed = true
+		}
+	}
+	if changed {
+		c.RawQuery = q.Encode()
+	}
+	return &c
+}
+
+// redactHeader returns a copy of header with the values of RedactHeaders replaced.
+func redactHeader(header http.Header) http.Header {
+	c := header.Clone()
+	for name := range c {
+		if redactedHeader(name) {
+			for i := range c[name] {
+				c[name][i] = Redacted
+			}
+		}
+	}
+	return c
+}
+
+func redactedHeader(name string) bool {
+	for _, h := range RedactHeaders {
+		if strings.EqualFold(name, h) {
+			return true
+		}
+	}
+	return false
+}
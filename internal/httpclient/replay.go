@@ -0,0 +1,120 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// hopHeaders are set by the transport and must not be copied into replayed requests.
+var hopHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Host":              true,
+	"Keep-Alive":        true,
+	"Proxy-Connection":  true,
+	"Te":                true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	// The transport only decompresses responses transparently if it added Accept-Encoding itself.
+	"Accept-Encoding": true,
+}
+
+// NewRequest rebuilds the recorded request. Truncated request bodies are sent as recorded.
+func (e *HAREntry) NewRequest() (*http.Request, error) {
+	var body io.Reader
+	if p := e.Request.PostData; p != nil {
+		if p.Encoding == "base64" {
+			b, err := base64.StdEncoding.DecodeString(p.Text)
+			if err != nil {
+				return nil, err
+			}
+			body = strings.NewReader(string(b))
+		} else {
+			body = strings.NewReader(p.Text)
+		}
+	}
+
+	req, err := http.NewRequest(e.Request.Method, e.Request.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range e.Request.Headers {
+		name := http.CanonicalHeaderKey(h.Name)
+		if name == "Host" {
+			req.Host = h.Value
+			continue
+		}
+		// HTTP/2 pseudo headers recorded by browsers.
+		if hopHeaders[name] || strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		req.Header.Add(h.Name, h.Value)
+	}
+	return req, nil
+}
+
+// ReplayResult compares the response to a replayed request with the recorded one. BodyChanged is only
+// meaningful when the recorded body was not truncated.
+type ReplayResult struct {
+	Index       int
+	Method      string
+	URL         string
+	Want        int
+	Got         int
+	BodyChanged bool
+	Err         error
+}
+
+// Changed reports whether the replayed request failed or got a different status or body than recorded.
+func (r ReplayResult) Changed() bool {
+	return r.Err != nil || r.Want != r.Got || r.BodyChanged
+}
+
+// Replay re-issues every request of h in order with client and compares the responses with the recorded ones.
+func Replay(client *http.Client, h *HAR) []ReplayResult {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	results := make([]ReplayResult, len(h.Log.Entries))
+	for i := range h.Log.Entries {
+		e := &h.Log.Entries[i]
+		r := ReplayResult{Index: i, Method: e.Request.Method, URL: e.Request.URL, Want: e.Response.Status}
+		r.Got, r.BodyChanged, r.Err = replay(client, e)
+		results[i] = r
+	}
+	return results
+}
+
+func replay(client *http.Client, e *HAREntry) (int, bool, error) {
+	req, err := e.NewRequest()
+	if err != nil {
+		return 0, false, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, false, err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, false, err
+	}
+
+	content := e.Response.Content
+	// Bodies that weren't recorded, or only partially, can't be compared.
+	if content.Comment != "" || content.Text == "" && content.Size > 0 {
+		return res.StatusCode, false, nil
+	}
+	recorded := []byte(content.Text)
+	if content.Encoding == "base64" {
+		if recorded, err = base64.StdEncoding.DecodeString(content.Text); err != nil {
+			return res.StatusCode, false, err
+		}
+	}
+	return res.StatusCode, !bytes.Equal(recorded, body), nil
+}
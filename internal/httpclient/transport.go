@@ -0,0 +1,132 @@
+package httpclient
+
+import (
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// userAgentTransport sets the User-Agent of every request to the next entry of agents.
+type userAgentTransport struct {
+	next   http.RoundTripper
+	agents []string
+	n      uint32
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	i := atomic.AddUint32(&t.n, 1) - 1
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.agents[int(i)%len(t.agents)])
+	return t.next.RoundTrip(req)
+}
+
+// retryTransport retries requests after network errors, 429 and 5xx responses. Requests with a body are only
+// retried if the body can be recreated through GetBody.
+type retryTransport struct {
+	next    http.RoundTripper
+	retries int
+	backoff time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	delay := t.backoff
+	for attempt := 0; ; attempt++ {
+		res, err := t.next.RoundTrip(req)
+		if attempt >= t.retries || !retryable(res, err) {
+			return res, err
+		}
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return res, err
+			}
+			body, gerr := req.GetBody()
+			if gerr != nil {
+				return res, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		wait := delay
+		if res != nil {
+			if d, ok := retryAfter(res); ok {
+				wait = d
+			}
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		delay *= 2
+	}
+}
+
+func retryable(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+}
+
+// retryAfter parses a Retry-After header given in seconds.
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	secs, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0, false
+	}
+	return time.Duration(secs) * time.Second, true
+}
+
+// logTransport logs every request and response. The values of RedactParams and RedactHeaders are replaced, so
+// logs can be shared without leaking API keys or sessions.
+type logTransport struct {
+	next   http.RoundTripper
+	logger *log.Logger
+	dump   bool
+}
+
+func (t *logTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u := redactURL(req.URL)
+	if t.dump {
+		dump := req.Clone(req.Context())
+		dump.URL = u
+		dump.Header = redactHeader(req.Header)
+		if b, err := httputil.DumpRequestOut(dump, true); err == nil {
+			t.logger.Printf("> %s\n%s", u, b)
+		}
+		// DumpRequestOut read the body and left a copy on dump.
+		req = req.Clone(req.Context())
+		req.Body = dump.Body
+	} else {
+		t.logger.Printf("> %s %s", req.Method, u)
+	}
+
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		t.logger.Printf("< %s %s: %v (%s)", req.Method, u, err, elapsed)
+		return res, err
+	}
+
+	if t.dump {
+		dump := *res
+		dump.Header = redactHeader(res.Header)
+		if b, err := httputil.DumpResponse(&dump, true); err == nil {
+			t.logger.Printf("< %s (%s)\n%s", u, elapsed, b)
+		}
+		res.Body = dump.Body
+	} else {
+		t.logger.Printf("< %s %s: %s (%s)", req.Method, u, res.Status, elapsed)
+	}
+	return res, err
+}
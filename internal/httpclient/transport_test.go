@@ -0,0 +1,50 @@
+package httpclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogTransportRedacts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "server-secret"})
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	for _, dump := range []bool{false, true} {
+		var buf bytes.Buffer
+		client, err := New(Config{Logger: log.New(&buf, "", 0), Dump: dump})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, _ := http.NewRequest("POST", srv.URL+"/host?key=url-secret&q=apache", strings.NewReader("payload"))
+		req.Header.Set("Authorization", "Bearer header-secret")
+		req.AddCookie(&http.Cookie{Name: "session", Value: "cookie-secret"})
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if string(body) != "payload" {
+			t.Errorf("dump=%v: body %q, want the request body echoed", dump, body)
+		}
+
+		logged := buf.String()
+		for _, secret := range []string{"url-secret", "header-secret", "cookie-secret", "server-secret"} {
+			if strings.Contains(logged, secret) {
+				t.Errorf("dump=%v: %s leaked into the log:\n%s", dump, secret, logged)
+			}
+		}
+		if !strings.Contains(logged, "q=apache") {
+			t.Errorf("dump=%v: other query parameters missing from the log:\n%s", dump, logged)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Package output prints the results of the command line tools in this repository as a table, JSON or CSV.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Writer prints results in one of the formats "table", "json" or "csv".
+type Writer struct {
+	Format string
+	Out    io.Writer
+}
+
+// New returns a Writer for format that prints to stdout.
+func New(format string) *Writer {
+	return &Writer{Format: strings.ToLower(format), Out: os.Stdout}
+}
+
+// Write prints v as JSON, or header and rows as a table or CSV depending on the selected format.
+func (w *Writer) Write(v interface{}, header []string, rows [][]string) error {
+	switch w.Format {
+	case "json":
+		enc := json.NewEncoder(w.Out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "csv":
+		cw := csv.NewWriter(w.Out)
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	case "table":
+		tw := tabwriter.NewWriter(w.Out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", w.Format)
+	}
+}